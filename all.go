@@ -0,0 +1,71 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/utils"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// TemplateMatch is a template of a set that matched a path.
+type TemplateMatch struct {
+	// Index is the position of the template in the set.
+	Index int
+	// Vars are the variables captured by the template.
+	Vars map[string]string
+	// Score is the specificity rank of the template among the matches, as
+	// defined by Compare: 0 for the most specific ones, 1 for the next ones,
+	// and so on. Matches with the same score are equally specific.
+	Score int
+	// Prefix and Remaining are the matched part of the path and its rest,
+	// as in Result. Remaining is always "" for MatchAll.
+	Prefix    string
+	Remaining string
+}
+
+// MatchAll matches every template of a set against the whole path and
+// returns all the matches, not only the best one. They are ordered from the
+// most to the least specific, as defined by Compare; equally specific
+// templates keep their order in the set.
+//
+// Example:
+//
+//	templates := []*pathmatchpb.PathTemplate{anyUser, me} // "/users/{id}", "/users/me"
+//	matches, _ := pathmatch.MatchAll(templates, "/users/me")
+//	// matches[0]: {Index: 1, Vars: {}, Score: 0, Prefix: "/users/me"}
+//	// matches[1]: {Index: 0, Vars: {"id": "me"}, Score: 1, Prefix: "/users/me"}
+func MatchAll(templates []*pathmatchpb.PathTemplate, path string, opts ...MatchOption) ([]TemplateMatch, error) {
+	return matchAll(templates, path, false, opts)
+}
+
+// MatchAllPrefix is like MatchAll, but each template is matched against a
+// prefix of the path, as with MatchPrefix.
+func MatchAllPrefix(templates []*pathmatchpb.PathTemplate, path string, opts ...MatchOption) ([]TemplateMatch, error) {
+	return matchAll(templates, path, true, opts)
+}
+
+func matchAll(templates []*pathmatchpb.PathTemplate, path string, prefix bool, opts []MatchOption) ([]TemplateMatch, error) {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+
+	candidates, err := match.MatchAll(templates, path, mopts, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := utils.Split(path)
+	matches := make([]TemplateMatch, len(candidates))
+	for i, c := range candidates {
+		matches[i] = TemplateMatch{
+			Index:  c.Index,
+			Vars:   c.Vars,
+			Score:  c.Score,
+			Prefix: utils.Join(segments[:c.N]...),
+		}
+		if c.N < len(segments) {
+			matches[i].Remaining = utils.Join(segments[c.N:]...)
+		}
+	}
+	return matches, nil
+}
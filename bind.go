@@ -0,0 +1,29 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Bind returns a copy of a parsed template in which the given variables are
+// fixed to their values. Bound variables become literal segments, and the
+// other variables are left as they are, so that the result matches the
+// paths of the template with those values.
+//
+// A sub-pattern variable becomes one literal segment per segment of its
+// value, a variable of a composite segment is merged with the literals
+// around it, and an optional group holding a bound variable becomes
+// required, unless the variable is bound to its default value: binding
+// "format" to "json" in "/export/{id}/{format?=json}" gives
+// "/export/{id}[/json]", which still matches "/export/7". Values are
+// checked as by Expand, and a value for a variable the template does not
+// have is reported with ErrExtraVariable.
+//
+// Example:
+//
+//	tmpl, _ := pathmatch.ParseTemplate("/tenants/{tenant}/users/{id}")
+//	bound, _ := pathmatch.Bind(tmpl, map[string]string{"tenant": "acme"})
+//	// bound matches "/tenants/acme/users/{id}"
+func Bind(template *pathmatchpb.PathTemplate, vars map[string]string) (*pathmatchpb.PathTemplate, error) {
+	return expand.Bind(template, vars)
+}
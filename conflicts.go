@@ -0,0 +1,44 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Conflict reports two templates of a set that match a common path and that
+// Compare cannot order, so that neither wins when both match.
+type Conflict struct {
+	// A and B are the indices of the templates in the set, with A < B.
+	A, B int
+	// Path is an example path matched by both templates.
+	Path string
+}
+
+// FindConflicts returns every pair of templates that match a common path
+// and that are equally specific as defined by Compare, such as "/items/{id}"
+// and "/items/{sku}". Each conflict comes with an example path matched by
+// both templates. The conflicts are ordered by A, then by B.
+//
+// The options are those used to match paths against the templates, e.g.
+// WithCaseInsensitive makes "/Users/{id}" and "/users/{id}" conflict.
+//
+// A common path of two templates with variable constraints may be missed
+// when the constraints have few values in common. Every conflict reported
+// is real: its example path matches both templates.
+func FindConflicts(templates []*pathmatchpb.PathTemplate, opts ...MatchOption) ([]Conflict, error) {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+
+	found, err := lang.Conflicts(templates, mopts)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := make([]Conflict, len(found))
+	for i, c := range found {
+		conflicts[i] = Conflict(c)
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,33 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Covers reports whether template a matches every path matched by template
+// b, e.g. whether a permission granted on a implies the one on b:
+//
+//	broad, _ := pathmatch.ParseTemplate("/orgs/{org}/**")
+//	narrow, _ := pathmatch.ParseTemplate("/orgs/*/repos/{repo}")
+//	pathmatch.Covers(broad, narrow) // true
+//	pathmatch.Covers(narrow, broad) // false
+//
+// The options are those used to match paths against the templates, e.g.
+// WithCaseInsensitive makes "/Users/{id}" cover "/users/{id}".
+//
+// The answer is exact for templates made of literals, alternations, '*',
+// '**', variables without constraints, sub-patterns and optional groups.
+// Constraints and composite segments are compared as a whole: a segment of
+// b such as "{id:int}" or "{name}.json" is covered by a segment of a
+// matching all its values, like '*' or an equal segment, so Covers may
+// report false for a template that does cover the other one. It never
+// reports true for one that does not.
+func Covers(a, b *pathmatchpb.PathTemplate, opts ...MatchOption) bool {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+	return lang.Covers(a, b, mopts)
+}
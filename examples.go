@@ -0,0 +1,67 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Generator returns the value of a variable for the i-th example generated
+// by Examples, starting at 0.
+type Generator func(i int) string
+
+type ExampleOption func(*lang.ExampleOptions)
+
+// WithGenerator sets the generator of the values of the variable name.
+// A variable with a sub-pattern takes a '/'-separated value, such as
+// "shelves/1" for "{name=shelves/*}".
+func WithGenerator(name string, gen Generator) ExampleOption {
+	return func(opts *lang.ExampleOptions) {
+		if opts.Generators == nil {
+			opts.Generators = make(map[string]func(int) string)
+		}
+		opts.Generators[name] = gen
+	}
+}
+
+// WithBoundary makes Examples start with the boundary cases of the
+// template: one where every '**' takes no segment and every optional group
+// is skipped, and one where every '**' takes several segments and every
+// optional group is taken.
+func WithBoundary() ExampleOption {
+	return func(opts *lang.ExampleOptions) {
+		opts.Boundary = true
+	}
+}
+
+// WithCaseVariants adds a boundary example with literals in upper case,
+// such as "/USERS/id3" for "/users/{id}". It implies WithBoundary, and the
+// examples are matched by the template with WithCaseInsensitive.
+func WithCaseVariants() ExampleOption {
+	return func(opts *lang.ExampleOptions) {
+		opts.Boundary = true
+		opts.CaseVariants = true
+	}
+}
+
+// Examples returns up to n distinct paths matched by the template, for
+// instance to property-test a handler or to document an API:
+//
+//	tmpl, _ := pathmatch.ParseTemplate("/users/{id:int}/files/{path=**}")
+//	paths, _ := pathmatch.Examples(tmpl, 2)
+//	// paths: ["/users/1/files/a", "/users/2/files/a/b"]
+//
+// Variables take the value of their generator, set with WithGenerator, or
+// a value derived from their name, such as "id1" for "{id}", or a sample
+// of their type or regex. A generated value that does not match its
+// variable makes Examples return ErrInvalidValue.
+//
+// Every path returned is matched by the template. Fewer than n paths are
+// returned when the template matches fewer distinct paths, as for a
+// template made of literals, or when no sample satisfies a regex.
+func Examples(template *pathmatchpb.PathTemplate, n int, opts ...ExampleOption) ([]string, error) {
+	eopts := &lang.ExampleOptions{}
+	for _, opt := range opts {
+		opt(eopts)
+	}
+	return lang.Examples(template, n, eopts)
+}
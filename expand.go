@@ -0,0 +1,43 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+var (
+	// ErrMissingVariable is returned by Expand when a variable of the template has no value.
+	ErrMissingVariable = expand.ErrMissingVariable
+	// ErrExtraVariable is returned by Expand when a value is given for a
+	// variable the template does not have.
+	ErrExtraVariable = expand.ErrExtraVariable
+	// ErrInvalidValue is returned by Expand when a value does not match its
+	// variable's sub-pattern or constraint, or is empty.
+	ErrInvalidValue = expand.ErrInvalidValue
+	// ErrNotExpandable is returned by Expand for templates with wildcards or
+	// alternations outside of variables, which have no value to expand.
+	ErrNotExpandable = expand.ErrNotExpandable
+)
+
+// Expand builds a concrete path from a parsed template and variable values,
+// the reverse of Match. Literals are rendered as is, and variables are
+// substituted with their values, so that the path matches the template.
+//
+// Each value is checked against its variable: a sub-pattern variable such as
+// "{name=shelves/*}" takes a '/'-separated value like "shelves/1" and
+// rejects "foo", and a constrained variable such as "{id:int}" rejects
+// "abc". The segments of the path are percent-escaped, so "a b/c" for
+// "{id}" becomes "a%20b%2Fc"; use MatchURL or WithUnescaping to match the
+// path back. Values have all reserved characters escaped, ':' included, so
+// that "1:delete" for "{id}" is not read as a custom verb.
+//
+// An optional group is rendered when one of its variables has a value.
+//
+// Example:
+//
+//	tmpl, _ := pathmatch.ParseTemplate("/v1/{name=shelves/*}/books/{book}")
+//	path, _ := pathmatch.Expand(tmpl, map[string]string{"name": "shelves/1", "book": "2"})
+//	// path: "/v1/shelves/1/books/2"
+func Expand(template *pathmatchpb.PathTemplate, vars map[string]string) (string, error) {
+	return expand.Expand(template, vars)
+}
@@ -0,0 +1,164 @@
+package expand
+
+import (
+	"errors"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Bind returns a copy of the template in which the variables with a value
+// become literals. A sub-pattern variable becomes one literal segment per
+// segment of its value. An optional group holding a bound variable becomes
+// required, as its variable is only captured when the group matches, unless
+// every bound variable of the group takes its default value: "{format?=json}"
+// bound to "json" becomes "[/json]".
+// Values are checked as by Expand.
+func Bind(template *pathmatchpb.PathTemplate, vars map[string]string) (*pathmatchpb.PathTemplate, error) {
+	if template == nil {
+		return nil, errors.New("template cannot be nil")
+	}
+
+	b := &binder{vars: vars, used: make(map[string]bool, len(vars))}
+	segments, err := b.segments(template.Segments)
+	if err != nil {
+		return nil, err
+	}
+	if err := extraError(vars, b.used); err != nil {
+		return nil, err
+	}
+	return &pathmatchpb.PathTemplate{Segments: segments, Verb: template.GetVerb()}, nil
+}
+
+type binder struct {
+	vars map[string]string
+	used map[string]bool
+}
+
+func (b *binder) segments(segs []*pathmatchpb.Segment) ([]*pathmatchpb.Segment, error) {
+	var bound []*pathmatchpb.Segment
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Variable:
+			value, ok := b.vars[s.Variable.Name]
+			if !ok {
+				break
+			}
+			b.used[s.Variable.Name] = true
+
+			if !match.HasSubPattern(s.Variable) {
+				if err := checkSingle(seg, s.Variable.Name, value); err != nil {
+					return nil, err
+				}
+				bound = append(bound, literal(value))
+				continue
+			}
+			values, err := splitMulti(seg, s.Variable.Name, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range values {
+				bound = append(bound, literal(v))
+			}
+			continue
+
+		case *pathmatchpb.Segment_Composite:
+			if !hasValue(s.Composite.Parts, b.vars) {
+				break
+			}
+			parts, err := b.parts(s.Composite.Parts)
+			if err != nil {
+				return nil, err
+			}
+			if len(parts) == 1 {
+				if _, ok := parts[0].Segment.(*pathmatchpb.Segment_Literal); ok {
+					bound = append(bound, parts[0])
+					continue
+				}
+			}
+			bound = append(bound, &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Composite{
+				Composite: &pathmatchpb.Composite{Parts: parts},
+			}})
+			continue
+
+		case *pathmatchpb.Segment_Optional:
+			inner, err := b.segments(s.Optional.Segments)
+			if err != nil {
+				return nil, err
+			}
+			if hasValue(s.Optional.Segments, b.vars) && !b.defaults(s.Optional.Segments) {
+				bound = append(bound, inner...)
+			} else {
+				bound = append(bound, &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Optional{
+					Optional: &pathmatchpb.Optional{Segments: inner},
+				}})
+			}
+			continue
+		}
+
+		bound = append(bound, proto.Clone(seg).(*pathmatchpb.Segment))
+	}
+	return bound, nil
+}
+
+// defaults reports whether the variables in segs with a value are all
+// bound to their default value, so that skipping their group captures the
+// same values.
+func (b *binder) defaults(segs []*pathmatchpb.Segment) bool {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Variable:
+			if value, ok := b.vars[s.Variable.Name]; ok && (s.Variable.DefaultValue == "" || value != s.Variable.DefaultValue) {
+				return false
+			}
+		case *pathmatchpb.Segment_Composite:
+			if !b.defaults(s.Composite.Parts) {
+				return false
+			}
+		case *pathmatchpb.Segment_Optional:
+			if !b.defaults(s.Optional.Segments) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parts binds the variables of a composite segment. The literals around a
+// bound variable are merged with its value.
+func (b *binder) parts(parts []*pathmatchpb.Segment) ([]*pathmatchpb.Segment, error) {
+	var bound []*pathmatchpb.Segment
+	appendLiteral := func(value string) {
+		if n := len(bound); n > 0 {
+			if lit, ok := bound[n-1].Segment.(*pathmatchpb.Segment_Literal); ok {
+				lit.Literal.Value += value
+				return
+			}
+		}
+		bound = append(bound, literal(value))
+	}
+
+	for _, part := range parts {
+		switch p := part.Segment.(type) {
+		case *pathmatchpb.Segment_Literal:
+			appendLiteral(p.Literal.Value)
+			continue
+		case *pathmatchpb.Segment_Variable:
+			if value, ok := b.vars[p.Variable.Name]; ok {
+				b.used[p.Variable.Name] = true
+				if err := checkSingle(part, p.Variable.Name, value); err != nil {
+					return nil, err
+				}
+				appendLiteral(value)
+				continue
+			}
+		}
+		bound = append(bound, proto.Clone(part).(*pathmatchpb.Segment))
+	}
+	return bound, nil
+}
+
+func literal(value string) *pathmatchpb.Segment {
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Literal{Literal: &pathmatchpb.Literal{Value: value}}}
+}
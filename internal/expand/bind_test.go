@@ -0,0 +1,85 @@
+package expand_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		vars        map[string]string
+		expected    string
+	}{
+		{templateStr: "/tenants/{tenant}/users/{id}", vars: map[string]string{"tenant": "acme"}, expected: "/tenants/acme/users/{id}"},
+		{templateStr: "/tenants/{tenant}/users/{id}", expected: "/tenants/{tenant}/users/{id}"},
+		{templateStr: "/{x}/{x}/{y}", vars: map[string]string{"x": "1"}, expected: "/1/1/{y}"},
+		{templateStr: "/v1/{name=shelves/*}/books/{book}", vars: map[string]string{"name": "shelves/1"}, expected: "/v1/shelves/1/books/{book}"},
+		{templateStr: "/files/{path=**}", vars: map[string]string{"path": "/"}, expected: "/files"},
+		{templateStr: "/files/{name}.{ext}", vars: map[string]string{"ext": "json"}, expected: "/files/{name}.json"},
+		{templateStr: "/files/{name}.{ext}", vars: map[string]string{"name": "report", "ext": "json"}, expected: "/files/report.json"},
+		{templateStr: "/docs[/{page}]", vars: map[string]string{"page": "intro"}, expected: "/docs/intro"},
+		{templateStr: "/docs/{id}[/{page}]", vars: map[string]string{"id": "1"}, expected: "/docs/1[/{page}]"},
+		{templateStr: "/export/{id}/{format?=json}", vars: map[string]string{"format": "json"}, expected: "/export/{id}[/json]"},
+		{templateStr: "/export/{id}/{format?=json}", vars: map[string]string{"format": "csv"}, expected: "/export/{id}/csv"},
+		{templateStr: "/v1/{name}/**:publish", vars: map[string]string{"name": "a b"}, expected: "/v1/a b/**:publish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+			expected, err := parse.ParseTemplate(tt.expected)
+			require.NoError(t, err)
+			original := proto.Clone(tmpl)
+
+			bound, err := expand.Bind(tmpl, tt.vars)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(expected, bound), "expected %v, got %v", expected, bound)
+			require.True(t, proto.Equal(original, tmpl), "the template must not change")
+		})
+	}
+}
+
+func TestBindDefaultKeepsPaths(t *testing.T) {
+	// Binding a variable to its default must not drop the paths that skip
+	// its group
+	tmpl, err := parse.ParseTemplate("/export/{id}/{format?=json}")
+	require.NoError(t, err)
+	bound, err := expand.Bind(tmpl, map[string]string{"format": "json"})
+	require.NoError(t, err)
+
+	for path, expected := range map[string]bool{"/export/7": true, "/export/7/json": true, "/export/7/csv": false} {
+		matched, _, err := match.StrictMatch(bound, path, &match.MatchOptions{})
+		require.NoError(t, err)
+		require.Equal(t, expected, matched, path)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		vars        map[string]string
+		err         error
+	}{
+		{templateStr: "/users/{id}", vars: map[string]string{"tenant": "acme"}, err: expand.ErrExtraVariable},
+		{templateStr: "/v1/{name=shelves/*}", vars: map[string]string{"name": "foo"}, err: expand.ErrInvalidValue},
+		{templateStr: "/items/{id:int}", vars: map[string]string{"id": "abc"}, err: expand.ErrInvalidValue},
+		{templateStr: "/files/{name:int}.{ext}", vars: map[string]string{"name": "abc"}, err: expand.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+
+			_, err = expand.Bind(tmpl, tt.vars)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package expand builds concrete paths from templates and variable values.
+package expand
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+var (
+	ErrMissingVariable = errors.New("missing variable")
+	ErrExtraVariable   = errors.New("unexpected variable")
+	ErrInvalidValue    = errors.New("invalid variable value")
+	ErrNotExpandable   = errors.New("segment cannot be expanded")
+)
+
+// Expand renders a template with the given variable values. Values are
+// checked against the variables' sub-patterns and constraints, and their
+// segments are percent-escaped, reserved characters included. A sub-pattern value is a '/'-separated
+// path, with or without a leading slash, as captured by the matcher.
+func Expand(template *pathmatchpb.PathTemplate, vars map[string]string) (string, error) {
+	if template == nil {
+		return "", errors.New("template cannot be nil")
+	}
+
+	e := &expander{vars: vars, used: make(map[string]bool, len(vars))}
+	if err := e.segments(template.Segments); err != nil {
+		return "", err
+	}
+
+	if err := extraError(vars, e.used); err != nil {
+		return "", err
+	}
+
+	if e.b.Len() == 0 {
+		e.b.WriteByte('/')
+	}
+	if verb := template.GetVerb(); verb != "" {
+		e.b.WriteString(":" + verb)
+	}
+	return e.b.String(), nil
+}
+
+type expander struct {
+	vars map[string]string
+	used map[string]bool
+	b    strings.Builder
+}
+
+func (e *expander) segments(segs []*pathmatchpb.Segment) error {
+	for _, seg := range segs {
+		if err := e.segment(seg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *expander) segment(seg *pathmatchpb.Segment) error {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		e.write(s.Literal.Value)
+		return nil
+
+	case *pathmatchpb.Segment_Variable:
+		value, err := e.value(s.Variable)
+		if err != nil {
+			return err
+		}
+		if !match.HasSubPattern(s.Variable) {
+			return e.single(seg, s.Variable.Name, value)
+		}
+		return e.multi(seg, s.Variable.Name, value)
+
+	case *pathmatchpb.Segment_Composite:
+		var value strings.Builder
+		for _, part := range s.Composite.Parts {
+			switch p := part.Segment.(type) {
+			case *pathmatchpb.Segment_Literal:
+				value.WriteString(p.Literal.Value)
+			case *pathmatchpb.Segment_Variable:
+				v, err := e.value(p.Variable)
+				if err != nil {
+					return err
+				}
+				value.WriteString(v)
+			default:
+				return fmt.Errorf("%w: wildcard or alternation in composite segment", ErrNotExpandable)
+			}
+		}
+		return e.single(seg, "", value.String())
+
+	case *pathmatchpb.Segment_Optional:
+		// The group is rendered if one of its variables has a value
+		if !e.hasValue(s.Optional.Segments) {
+			return nil
+		}
+		return e.segments(s.Optional.Segments)
+
+	case *pathmatchpb.Segment_Star, *pathmatchpb.Segment_DoubleStar:
+		return fmt.Errorf("%w: wildcard outside of a variable", ErrNotExpandable)
+
+	case *pathmatchpb.Segment_Alternation:
+		return fmt.Errorf("%w: alternation outside of a variable", ErrNotExpandable)
+	}
+	return errors.New("unexpected segment type in template")
+}
+
+// value returns the value of a variable and marks it as used.
+func (e *expander) value(v *pathmatchpb.Variable) (string, error) {
+	value, ok := e.vars[v.Name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrMissingVariable, v.Name)
+	}
+	e.used[v.Name] = true
+	return value, nil
+}
+
+// single writes the value of a single-segment variable or composite segment,
+// once checked against seg.
+func (e *expander) single(seg *pathmatchpb.Segment, name, value string) error {
+	if err := checkSingle(seg, name, value); err != nil {
+		return err
+	}
+	e.writeValue(value)
+	return nil
+}
+
+// multi writes the value of a sub-pattern variable, once checked against seg.
+func (e *expander) multi(seg *pathmatchpb.Segment, name, value string) error {
+	segments, err := splitMulti(seg, name, value)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		e.writeValue(s)
+	}
+	return nil
+}
+
+// checkSingle checks the value of a single-segment variable or composite
+// segment against seg.
+func checkSingle(seg *pathmatchpb.Segment, name, value string) error {
+	ok, err := match.MatchSegment(seg, value, &match.MatchOptions{})
+	if err != nil {
+		return err
+	}
+	if !ok || value == "" {
+		return invalidValue(name, value)
+	}
+	return nil
+}
+
+// splitMulti checks the value of a sub-pattern variable against seg,
+// and returns its segments.
+func splitMulti(seg *pathmatchpb.Segment, name, value string) ([]string, error) {
+	value = "/" + strings.TrimPrefix(value, "/")
+	segments := strings.Split(value[1:], "/")
+	if value == "/" {
+		segments = nil
+	}
+	if slices.Contains(segments, "") {
+		return nil, invalidValue(name, value)
+	}
+
+	tmpl := &pathmatchpb.PathTemplate{Segments: []*pathmatchpb.Segment{seg}}
+	matched, _, err := match.StrictMatch(tmpl, value, &match.MatchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, invalidValue(name, value)
+	}
+	return segments, nil
+}
+
+func (e *expander) write(segment string) {
+	e.b.WriteByte('/')
+	e.b.WriteString(url.PathEscape(segment))
+}
+
+// writeValue writes a segment taken from a variable value. Unlike a
+// literal, it has every reserved character escaped, so that a value such
+// as "1:delete" cannot add a verb to the path.
+func (e *expander) writeValue(segment string) {
+	e.b.WriteByte('/')
+	e.b.WriteString(escapeValue(segment))
+}
+
+// escapeValue percent-encodes every character of s but the RFC 3986
+// unreserved ones: letters, digits, '-', '.', '_' and '~'.
+func escapeValue(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// hasValue reports whether one of the variables in segs has a value.
+func (e *expander) hasValue(segs []*pathmatchpb.Segment) bool {
+	return hasValue(segs, e.vars)
+}
+
+func hasValue(segs []*pathmatchpb.Segment, vars map[string]string) bool {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Variable:
+			if _, ok := vars[s.Variable.Name]; ok {
+				return true
+			}
+		case *pathmatchpb.Segment_Composite:
+			if hasValue(s.Composite.Parts, vars) {
+				return true
+			}
+		case *pathmatchpb.Segment_Optional:
+			if hasValue(s.Optional.Segments, vars) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extraError returns an ErrExtraVariable error for the variables not used,
+// or nil.
+func extraError(vars map[string]string, used map[string]bool) error {
+	var extra []string
+	for name := range vars {
+		if !used[name] {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	slices.Sort(extra)
+	return fmt.Errorf("%w: %q", ErrExtraVariable, extra)
+}
+
+func invalidValue(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("%w: composite segment value %q does not match", ErrInvalidValue, value)
+	}
+	return fmt.Errorf("%w: %q for variable %q", ErrInvalidValue, value, name)
+}
@@ -0,0 +1,104 @@
+package expand_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		vars        map[string]string
+		expected    string
+	}{
+		{templateStr: "/", expected: "/"},
+		{templateStr: "/users/{id}", vars: map[string]string{"id": "alice"}, expected: "/users/alice"},
+		{templateStr: "/users/{id}", vars: map[string]string{"id": "a b/c?"}, expected: "/users/a%20b%2Fc%3F"},
+		{templateStr: "/users/{id}", vars: map[string]string{"id": "a=b&c+d@e$"}, expected: "/users/a%3Db%26c%2Bd%40e%24"},
+		{templateStr: "/{x}/{x}", vars: map[string]string{"x": "1"}, expected: "/1/1"},
+		{templateStr: "/v1/{name=shelves/*}", vars: map[string]string{"name": "shelves/1"}, expected: "/v1/shelves/1"},
+		{templateStr: "/v1/{name=shelves/*}", vars: map[string]string{"name": "/shelves/1"}, expected: "/v1/shelves/1"},
+		{templateStr: "/files/{path=**}", vars: map[string]string{"path": "/a/b c"}, expected: "/files/a/b%20c"},
+		{templateStr: "/files/{path=**}", vars: map[string]string{"path": "/"}, expected: "/files"},
+		{templateStr: "/files/{name}.{ext}", vars: map[string]string{"name": "report", "ext": "pdf"}, expected: "/files/report.pdf"},
+		{templateStr: "/items/{id:int}", vars: map[string]string{"id": "42"}, expected: "/items/42"},
+		{templateStr: "/docs[/{page}]", expected: "/docs"},
+		{templateStr: "/docs[/{page}]", vars: map[string]string{"page": "intro"}, expected: "/docs/intro"},
+		{templateStr: "/export/{id}/{format?=json}", vars: map[string]string{"id": "7"}, expected: "/export/7"},
+		{templateStr: "/export/{id}/{format?=json}", vars: map[string]string{"id": "7", "format": "csv"}, expected: "/export/7/csv"},
+		{templateStr: "/v1/{name}:publish", vars: map[string]string{"name": "book"}, expected: "/v1/book:publish"},
+		{templateStr: "/{kind=(users|groups)}", vars: map[string]string{"kind": "groups"}, expected: "/groups"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+
+			path, err := expand.Expand(tmpl, tt.vars)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, path)
+
+			// The path matches the template back
+			matched, _, err := match.StrictMatch(tmpl, path, &match.MatchOptions{Unescape: match.UnescapeAll})
+			require.NoError(t, err)
+			require.True(t, matched)
+		})
+	}
+}
+
+func TestExpandEscapesVerb(t *testing.T) {
+	// A ':' in a value must not turn the path into one with a verb
+	get, err := parse.ParseTemplate("/v1/books/{id}")
+	require.NoError(t, err)
+	del, err := parse.ParseTemplate("/v1/books/{id}:delete")
+	require.NoError(t, err)
+
+	router := match.NewRouter(&match.MatchOptions{Unescape: match.UnescapeAll})
+	require.NoError(t, router.Add("get", get))
+	require.NoError(t, router.Add("delete", del))
+
+	path, err := expand.Expand(get, map[string]string{"id": "1:delete"})
+	require.NoError(t, err)
+	require.Equal(t, "/v1/books/1%3Adelete", path)
+
+	id, vars, matched, err := router.Match(path)
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, "get", id)
+	require.Equal(t, map[string]string{"id": "1:delete"}, vars)
+}
+
+func TestExpandError(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		vars        map[string]string
+		err         error
+	}{
+		{templateStr: "/users/{id}", err: expand.ErrMissingVariable},
+		{templateStr: "/users/{id}", vars: map[string]string{"id": "1", "extra": "x"}, err: expand.ErrExtraVariable},
+		{templateStr: "/users/{id}", vars: map[string]string{"id": ""}, err: expand.ErrInvalidValue},
+		{templateStr: "/v1/{name=shelves/*}", vars: map[string]string{"name": "foo"}, err: expand.ErrInvalidValue},
+		{templateStr: "/v1/{name=shelves/*}", vars: map[string]string{"name": "shelves//1"}, err: expand.ErrInvalidValue},
+		{templateStr: "/items/{id:int}", vars: map[string]string{"id": "abc"}, err: expand.ErrInvalidValue},
+		{templateStr: "/items/{id:[a-z]+}", vars: map[string]string{"id": "123"}, err: expand.ErrInvalidValue},
+		{templateStr: "/docs[/{page}/{section}]", vars: map[string]string{"page": "intro"}, err: expand.ErrMissingVariable},
+		{templateStr: "/files/*", err: expand.ErrNotExpandable},
+		{templateStr: "/files/**", err: expand.ErrNotExpandable},
+		{templateStr: "/api/(v1|v2)", err: expand.ErrNotExpandable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+
+			_, err = expand.Expand(tmpl, tt.vars)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package lang
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/rank"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Conflict is a pair of templates that match a common path and that the
+// specificity ranking cannot order.
+type Conflict struct {
+	// A and B are the indices of the templates, with A < B.
+	A, B int
+	// Path is a path matched by both templates.
+	Path string
+}
+
+// Conflicts returns the conflicting pairs of templates, ordered by A then B.
+func Conflicts(templates []*pathmatchpb.PathTemplate, opts *match.MatchOptions) ([]Conflict, error) {
+	for _, tmpl := range templates {
+		if tmpl == nil {
+			return nil, errors.New("template cannot be nil")
+		}
+	}
+
+	var conflicts []Conflict
+	for i, a := range templates {
+		for j := i + 1; j < len(templates); j++ {
+			b := templates[j]
+			if rank.Compare(a, b) != 0 {
+				continue
+			}
+			path, ok, err := Witness(a, b, opts)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				conflicts = append(conflicts, Conflict{A: i, B: j, Path: path})
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// Witness returns a path matched by both templates, if it finds one.
+//
+// The search is exact for templates without variable constraints. A common
+// path whose segments must satisfy the constraints of both templates is
+// looked for among sample values, so it may be missed. Every path returned
+// is checked against both templates.
+func Witness(a, b *pathmatchpb.PathTemplate, opts *match.MatchOptions) (string, bool, error) {
+	if !compareVerbs(a.GetVerb(), b.GetVerb(), opts.CaseInsensitive) {
+		// The verb is the text after the last colon of the path
+		return "", false, nil
+	}
+
+	type labels struct{ a, b *pathmatchpb.Segment }
+	type value struct {
+		s  string
+		ok bool
+	}
+	cache := make(map[labels]value)
+	meet := func(la, lb *pathmatchpb.Segment) (string, bool) {
+		key := labels{la, lb}
+		if v, ok := cache[key]; ok {
+			return v.s, v.ok
+		}
+		s, ok := commonValue(la, lb, opts)
+		cache[key] = value{s, ok}
+		return s, ok
+	}
+
+	segments, ok := witness(newSegmentNFA(a.GetSegments()), newSegmentNFA(b.GetSegments()), meet)
+	if !ok {
+		return "", false, nil
+	}
+
+	path := render(segments, a.GetVerb(), opts)
+	for _, tmpl := range []*pathmatchpb.PathTemplate{a, b} {
+		matched, _, err := match.StrictMatch(tmpl, path, opts)
+		if !matched || err != nil {
+			return "", false, err
+		}
+	}
+	return path, true, nil
+}
+
+// render builds a path from its segment values, escaped as the unescaping
+// mode expects.
+func render(segments []string, verb string, opts *match.MatchOptions) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		if opts.Unescape != match.UnescapeNone {
+			s = url.PathEscape(s)
+		}
+		b.WriteString(s)
+	}
+	if b.Len() == 0 {
+		b.WriteByte('/')
+	}
+	if verb != "" {
+		b.WriteString(":" + verb)
+	}
+	return b.String()
+}
+
+func compareVerbs(a, b string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,96 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func mustParse(t *testing.T, s string) *pmpb.PathTemplate {
+	t.Helper()
+	tmpl, err := parse.ParseTemplate(s)
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestWitness(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string // empty if the templates are disjoint
+		opts     match.MatchOptions
+	}{
+		{a: "/items/{id}", b: "/items/{sku}", expected: "/items/x"},
+		{a: "/items/{id}", b: "/items/*", expected: "/items/x"},
+		{a: "/items/{id}", b: "/users/{id}"},
+		{a: "/items/{id:int}", b: "/items/{sku}", expected: "/items/1"},
+		{a: "/items/{id:int}", b: "/items/{sku:uuid}"},
+		{a: `/items/{id:[a-f]{3}}`, b: "/items/{sku}", expected: "/items/aaa"},
+		{a: "/a/**", b: "/**/b", expected: "/a/b"},
+		{a: "/**/a/**", b: "/**/b/**", expected: "/b/a"},
+		{a: "/**", b: "/", expected: "/"},
+		{a: "/a/**/c", b: "/a/b"},
+		{a: "/v1/{name=shelves/*}", b: "/v1/{s}/{b}", expected: "/v1/shelves/x"},
+		{a: "/files/{name}.json", b: "/files/report.{ext}", expected: "/files/report.json"},
+		{a: "/files/{name}.json", b: "/files/{name}.yaml"},
+		{a: "/api/(v1|v2)", b: "/api/(v2|v3)", expected: "/api/v2"},
+		{a: "/api/(v1|v2)", b: "/api/(v3|v4)"},
+		{a: "/docs[/{page}]", b: "/docs", expected: "/docs"},
+		{a: "/docs[/a/**]", b: "/docs/b"},
+		{a: "/v1/{name}:publish", b: "/v1/{id}:publish", expected: "/v1/x:publish"},
+		{a: "/v1/{name}:publish", b: "/v1/{id}:archive"},
+		{a: "/Users/{id}", b: "/users/{id}"},
+		{
+			a:        "/Users/{id}",
+			b:        "/users/{id}",
+			expected: "/Users/x",
+			opts:     match.MatchOptions{CaseInsensitive: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			path, ok, err := lang.Witness(mustParse(t, tt.a), mustParse(t, tt.b), &tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected != "", ok)
+			require.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	input := []string{
+		"/items/{id}",
+		"/items/{sku}",
+		"/items/me",
+		"/items/*",
+		"/items/{id}/**",
+		"/items/{id:int}/**",
+		"/users/{id}",
+	}
+	templates := make([]*pmpb.PathTemplate, len(input))
+	for i, s := range input {
+		templates[i] = mustParse(t, s)
+	}
+
+	conflicts, err := lang.Conflicts(templates, &match.MatchOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []lang.Conflict{
+		{A: 0, B: 1, Path: "/items/x"},
+		{A: 0, B: 3, Path: "/items/x"},
+		{A: 1, B: 3, Path: "/items/x"},
+		{A: 4, B: 5, Path: "/items/1"},
+	}, conflicts)
+}
+
+func TestConflictsSingleStarSubPattern(t *testing.T) {
+	// "{sku=*}" matches the same segments as "{id}", so neither wins
+	templates := []*pmpb.PathTemplate{mustParse(t, "/items/{id}"), mustParse(t, "/items/{sku=*}")}
+
+	conflicts, err := lang.Conflicts(templates, &match.MatchOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []lang.Conflict{{A: 0, B: 1, Path: "/items/x"}}, conflicts)
+}
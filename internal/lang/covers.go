@@ -0,0 +1,208 @@
+package lang
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Covers reports whether template a matches every path matched by b.
+//
+// The check is exact for templates made of literals, alternations,
+// wildcards, unconstrained variables and optional groups. A segment of b
+// with a constraint or a composite segment is covered only by a single
+// segment of a matching all its values, such as a wildcard or an equal
+// segment. A variable of a with a sub-pattern and a constraint only covers
+// an equal template.
+func Covers(a, b *pathmatchpb.PathTemplate, opts *match.MatchOptions) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	segsA, segsB := a.GetSegments(), b.GetSegments()
+	var err error
+	switch {
+	case compareVerbs(a.GetVerb(), b.GetVerb(), opts.CaseInsensitive):
+	case a.GetVerb() == "":
+		// The last segment of a takes the verb of b
+		segsB, err = appendVerb(segsB, b.GetVerb())
+	case b.GetVerb() == "":
+		segsA, err = appendVerb(segsA, a.GetVerb())
+	default:
+		return false
+	}
+	return err == nil && covers(segsA, segsB, opts)
+}
+
+// covers reports whether the template segments x match every path matched
+// by y, as described for Covers. Intersect uses it as well, to drop the
+// alternatives of an intersection covered by another one.
+//
+// The automaton of y is run along the automaton of x determinized on the
+// fly. Its symbols are the literal values of both templates, a symbol for
+// any other value, and the constrained and composite segments of y, which
+// stand for all the values they match.
+func covers(x, y []*pathmatchpb.Segment, opts *match.MatchOptions) bool {
+	if equalSegments(x, y) {
+		return true
+	}
+	if hasConstrainedSubPattern(x) {
+		// The automaton would not check the constraint
+		return false
+	}
+
+	a, b := newSegmentNFA(x), newSegmentNFA(y)
+	values := literalSymbols(x, y)
+	emit := func(label *pathmatchpb.Segment) []symbol {
+		if isAny(label) {
+			return append(slices.Clip(values), symbol{other: true})
+		}
+		if vs, ok := literalValues(label); ok {
+			symbols := make([]symbol, len(vs))
+			for i, v := range vs {
+				symbols[i] = symbol{value: v}
+			}
+			return symbols
+		}
+		return []symbol{{class: label}}
+	}
+
+	type pair struct {
+		b int
+		a string
+	}
+	sets := make(map[string][]int)
+	seen := make(map[pair]bool)
+	var queue []pair
+	push := func(statesB []int, setA []int) {
+		key := fmt.Sprint(setA)
+		sets[key] = setA
+		for _, qb := range statesB {
+			if p := (pair{qb, key}); !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	push(closure(b, []int{0}), closure(a, []int{0}))
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		setA := sets[p.a]
+		if p.b == b.accept && !slices.Contains(setA, a.accept) {
+			return false
+		}
+
+		for _, tb := range b.edges[p.b] {
+			for _, s := range emit(tb.label) {
+				var next []int
+				for _, qa := range setA {
+					for _, ta := range a.edges[qa] {
+						if s.acceptedBy(ta.label, opts) {
+							next = append(next, ta.to)
+						}
+					}
+				}
+				push(closure(b, []int{tb.to}), closure(a, next))
+			}
+		}
+	}
+	return true
+}
+
+// symbol is a set of path segment values: a literal value, the values
+// different from every literal value, or the values matched by a segment.
+type symbol struct {
+	value string
+	other bool
+	class *pathmatchpb.Segment
+}
+
+// acceptedBy reports whether a transition label matches all the values of
+// the symbol.
+func (s symbol) acceptedBy(label *pathmatchpb.Segment, opts *match.MatchOptions) bool {
+	switch {
+	case s.other:
+		return isAny(label)
+	case s.class != nil:
+		return segmentCovers(label, s.class, opts)
+	}
+	ok, err := match.MatchSegment(label, s.value, opts)
+	return ok && err == nil
+}
+
+// literalSymbols returns the symbols of the literal values of segments.
+func literalSymbols(templates ...[]*pathmatchpb.Segment) []symbol {
+	var symbols []symbol
+	var add func(segs []*pathmatchpb.Segment)
+	add = func(segs []*pathmatchpb.Segment) {
+		for _, seg := range segs {
+			if values, ok := literalValues(seg); ok {
+				for _, v := range values {
+					symbols = append(symbols, symbol{value: v})
+				}
+			}
+			add(seg.GetVariable().GetSegments())
+			add(seg.GetOptional().GetSegments())
+		}
+	}
+	for _, segs := range templates {
+		add(segs)
+	}
+	return symbols
+}
+
+// closure returns the states reachable from states by epsilon transitions,
+// in increasing order.
+func closure(n *segmentNFA, states []int) []int {
+	seen := make(map[int]bool)
+	for len(states) > 0 {
+		s := states[len(states)-1]
+		states = states[:len(states)-1]
+		if !seen[s] {
+			seen[s] = true
+			states = append(states, n.eps[s]...)
+		}
+	}
+	reached := make([]int, 0, len(seen))
+	for s := range seen {
+		reached = append(reached, s)
+	}
+	slices.Sort(reached)
+	return reached
+}
+
+func hasConstrainedSubPattern(segs []*pathmatchpb.Segment) bool {
+	for _, seg := range segs {
+		if v := seg.GetVariable(); v != nil && len(v.Segments) > 0 && (v.GetType() != "" || v.GetRegex() != "") {
+			return true
+		}
+		if hasConstrainedSubPattern(seg.GetOptional().GetSegments()) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentCovers reports whether the single-segment segment x matches every
+// value matched by y.
+func segmentCovers(x, y *pathmatchpb.Segment, opts *match.MatchOptions) bool {
+	if isAny(x) {
+		return true
+	}
+	if isAny(y) {
+		return false
+	}
+	if values, ok := literalValues(y); ok {
+		for _, v := range values {
+			if ok, err := match.MatchSegment(x, v, opts); !ok || err != nil {
+				return false
+			}
+		}
+		return true
+	}
+	return sameSegment(x, y)
+}
@@ -0,0 +1,96 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+)
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+		opts     match.MatchOptions
+	}{
+		{a: "/orgs/{org}/**", b: "/orgs/*/repos/{repo}", expected: true},
+		{a: "/orgs/*/repos/{repo}", b: "/orgs/{org}/**"},
+		{a: "/orgs/{org}/**", b: "/orgs/{org}", expected: true},
+		{a: "/orgs/{org}/**", b: "/users/{id}"},
+		{a: "/**", b: "/a/**/b/{c=x/*}", expected: true},
+		{a: "/a/*", b: "/a/b", expected: true},
+		{a: "/a/b", b: "/a/*"},
+		{a: "/a/{x}", b: "/a/(b|c)", expected: true},
+		{a: "/a/(b|c|d)", b: "/a/(b|c)", expected: true},
+		{a: "/a/(b|c)", b: "/a/(b|d)"},
+		{a: "/**/b/**", b: "/a/**/b", expected: true},
+		{a: "/a/**/b/**", b: "/**/b/**"},
+		{a: "/**/a/**/b/**", b: "/a/**/b/**", expected: true},
+		{a: "/**/a/**/b/**", b: "/**/b/**/a/**"},
+		{a: "/x/**", b: "/x/**/**", expected: true},
+		{a: "/files/{path=**}", b: "/files/a/*", expected: true},
+		{a: "/files/{path=a/**}", b: "/files/**"},
+		{a: "/users/{id}[/profile]", b: "/users/*", expected: true},
+		{a: "/users/{id}[/profile]", b: "/users/*/profile", expected: true},
+		{a: "/users/{id}", b: "/users/*[/profile]"},
+		{a: "/a[/b]/**", b: "/a/**", expected: true},
+		{a: "/a/{id:int}", b: "/a/42", expected: true},
+		{a: "/a/{id:int}", b: "/a/x"},
+		{a: "/a/{id:int}", b: "/a/{n:int}", expected: true},
+		{a: "/a/{id:int}", b: "/a/*"},
+		{a: "/a/*", b: "/a/{id:int}", expected: true},
+		{a: "/f/{name}.json", b: "/f/(a.json|b.json)", expected: true},
+		{a: "/f/*", b: "/f/{name}.json", expected: true},
+		{a: "/f/{name}.json", b: "/f/*"},
+		{a: "/f/{p=**:[a-z/]+}", b: "/f/a"},
+		{a: "/f/{p=**:[a-z/]+}", b: "/f/{p=**:[a-z/]+}", expected: true},
+		{a: "/v1/{id}", b: "/v1/{id}:publish", expected: true},
+		{a: "/v1/{id}:publish", b: "/v1/{id}"},
+		{a: "/v1/{id}:publish", b: "/v1/{id}:delete"},
+		{a: "/v1/x:publish", b: "/v1/x\\:publish", expected: true},
+		{a: "/Users/{id}", b: "/users/{id}"},
+		{a: "/Users/{id}", b: "/users/{id}", expected: true, opts: match.MatchOptions{CaseInsensitive: true}},
+		{a: "/api/(V1|V2)/**", b: "/api/v1/x", expected: true, opts: match.MatchOptions{CaseInsensitive: true}},
+		{a: "/v1/{id}:Publish", b: "/v1/{id}:publish", expected: true, opts: match.MatchOptions{CaseInsensitive: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			require.Equal(t, tt.expected, lang.Covers(mustParse(t, tt.a), mustParse(t, tt.b), &tt.opts))
+		})
+	}
+}
+
+// TestCoversPaths checks that when a template covers another one, it
+// matches every path the other one matches.
+func TestCoversPaths(t *testing.T) {
+	templates := []string{
+		"/a/{x}", "/a/b", "/a/*/c", "/a/**", "/**/c", "/**", "/{x}/{y}/{z}",
+		"/a[/b]", "/a[/b[/c]]", "/a/(b|c)", "/{x}[/c]", "/a/{p=b/**}", "/{p=**}/c",
+		"/a/{x:int}", "/a/b:run", "/a/{x}:run", "/a/{x}.json",
+	}
+	paths := []string{
+		"/", "/a", "/b", "/a/b", "/a/c", "/a/1", "/a/b/c", "/a/x/c", "/a/b/b",
+		"/b/a/c", "/a/b/c/d", "/c", "/a/b:run", "/a/1:run", "/a/b.json", "/a/b/c/c",
+	}
+	opts := &match.MatchOptions{}
+
+	for _, sa := range templates {
+		for _, sb := range templates {
+			a, b := mustParse(t, sa), mustParse(t, sb)
+			if !lang.Covers(a, b, opts) {
+				continue
+			}
+			for _, path := range paths {
+				inB, _, err := match.StrictMatch(b, path, opts)
+				require.NoError(t, err)
+				if inB {
+					inA, _, err := match.StrictMatch(a, path, opts)
+					require.NoError(t, err)
+					require.True(t, inA, "%s covers %s but does not match %s", sa, sb, path)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// ExampleOptions configures Examples.
+type ExampleOptions struct {
+	// Generators return the value of a variable for the i-th example,
+	// by variable name.
+	Generators map[string]func(i int) string
+	// Boundary adds examples where every '**' takes no segment and every
+	// optional group is skipped, and where every '**' takes several
+	// segments and every optional group is taken.
+	Boundary bool
+	// CaseVariants adds an example with literals in upper case in boundary
+	// mode. Examples are then checked with case-insensitive matching.
+	CaseVariants bool
+}
+
+// boundaryStars is the number of segments taken by '**' in the boundary
+// example with long captures.
+const boundaryStars = 3
+
+// plan holds the choices made for an example.
+type plan struct {
+	index    int  // the index of the example, passed to generators
+	stars    int  // the number of segments taken by each '**'
+	optional bool // whether optional groups are taken
+	upper    bool // whether literals are in upper case
+}
+
+// Examples returns up to n distinct paths matched by the template. The
+// examples vary the values of variables and wildcards, the number of
+// segments taken by '**' and whether optional groups are taken.
+//
+// Variables without a generator take a value derived from their name or
+// index, or a sample value of their type or regex. Paths that do not match
+// the template, for instance because no sample satisfies a regex, are left
+// out, so fewer than n paths may be returned. A generated value that does
+// not match its variable is an error.
+func Examples(template *pathmatchpb.PathTemplate, n int, opts *ExampleOptions) ([]string, error) {
+	if template == nil {
+		return nil, errors.New("template cannot be nil")
+	}
+
+	var plans []plan
+	if opts.Boundary {
+		plans = append(plans, plan{}, plan{stars: boundaryStars, optional: true})
+		if opts.CaseVariants {
+			plans = append(plans, plan{stars: 1, optional: true, upper: true})
+		}
+	}
+	mopts := &match.MatchOptions{CaseInsensitive: opts.CaseVariants}
+
+	var examples []string
+	seen := make(map[string]bool)
+	// Examples may repeat, e.g. for templates without variables, so the
+	// number of attempts is bounded rather than the number of examples
+	for i := 0; len(examples) < n && i < len(plans)+2*n; i++ {
+		p := plan{index: i, stars: 1 + i%2, optional: i%2 == 0}
+		if i < len(plans) {
+			p = plans[i]
+			p.index = i
+		}
+
+		g := &exampleGenerator{plan: p, opts: opts}
+		segments, ok, err := g.segments(template.GetSegments())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		verb := template.GetVerb()
+		if p.upper {
+			verb = strings.ToUpper(verb)
+		}
+		path := render(segments, verb, &match.MatchOptions{})
+		if seen[path] {
+			continue
+		}
+		if matched, _, err := match.StrictMatch(template, path, mopts); !matched || err != nil {
+			continue
+		}
+		seen[path] = true
+		examples = append(examples, path)
+	}
+	return examples, nil
+}
+
+type exampleGenerator struct {
+	plan
+	opts *ExampleOptions
+}
+
+// segments returns the path segments of an example of segs, or false if
+// no example was found.
+func (g *exampleGenerator) segments(segs []*pathmatchpb.Segment) ([]string, bool, error) {
+	var path []string
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_DoubleStar:
+			for k := range g.stars {
+				path = append(path, string(rune('a'+k%26)))
+			}
+
+		case *pathmatchpb.Segment_Optional:
+			if !g.optional {
+				continue
+			}
+			inner, ok, err := g.segments(s.Optional.Segments)
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+			path = append(path, inner...)
+
+		case *pathmatchpb.Segment_Variable:
+			if !match.HasSubPattern(s.Variable) {
+				value, ok, err := g.single(seg)
+				if !ok || err != nil {
+					return nil, ok, err
+				}
+				path = append(path, value)
+				continue
+			}
+			values, ok, err := g.multi(seg, s.Variable)
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+			path = append(path, values...)
+
+		default:
+			value, ok, err := g.single(seg)
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+			path = append(path, value)
+		}
+	}
+	return path, true, nil
+}
+
+// single returns the value of a single-segment segment.
+func (g *exampleGenerator) single(seg *pathmatchpb.Segment) (string, bool, error) {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		return g.literal(s.Literal.Value), true, nil
+	case *pathmatchpb.Segment_Alternation:
+		return g.literal(s.Alternation.Values[g.index%len(s.Alternation.Values)]), true, nil
+	case *pathmatchpb.Segment_Star:
+		return "x" + strconv.Itoa(g.index+1), true, nil
+	case *pathmatchpb.Segment_Variable:
+		return g.variable(seg, s.Variable)
+	case *pathmatchpb.Segment_Composite:
+		return g.composite(seg, s.Composite)
+	}
+	return "", false, fmt.Errorf("unexpected segment type %T in template", seg.Segment)
+}
+
+// variable returns the value of a single-segment variable: its generated
+// value, or the first of its candidate values it matches.
+func (g *exampleGenerator) variable(seg *pathmatchpb.Segment, v *pathmatchpb.Variable) (string, bool, error) {
+	if gen, ok := g.opts.Generators[v.Name]; ok {
+		value := gen(g.index)
+		if ok, err := match.MatchSegment(seg, value, &match.MatchOptions{}); !ok || err != nil || value == "" || strings.Contains(value, "/") {
+			return "", false, errors.Join(generatedError(v.Name, value), err)
+		}
+		return value, true, nil
+	}
+
+	n := strconv.Itoa(g.index + 1)
+	candidates := append([]string{v.Name + n, n}, variableSamples(v)...)
+	for _, value := range candidates {
+		if matches(seg, value, &match.MatchOptions{}) {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// multi returns the segments of the value of a variable with a sub-pattern.
+func (g *exampleGenerator) multi(seg *pathmatchpb.Segment, v *pathmatchpb.Variable) ([]string, bool, error) {
+	var values []string
+	if gen, ok := g.opts.Generators[v.Name]; ok {
+		value := strings.TrimPrefix(gen(g.index), "/")
+		if value != "" {
+			values = strings.Split(value, "/")
+		}
+	} else {
+		var ok bool
+		var err error
+		if values, ok, err = g.segments(v.Segments); !ok || err != nil {
+			return nil, ok, err
+		}
+	}
+
+	// The sub-pattern and the constraint of the variable must hold
+	tmpl := &pathmatchpb.PathTemplate{Segments: []*pathmatchpb.Segment{seg}}
+	value := "/" + strings.Join(values, "/")
+	matched, _, err := match.StrictMatch(tmpl, value, &match.MatchOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	if !matched || strings.Contains(value, "//") {
+		if _, ok := g.opts.Generators[v.Name]; ok {
+			return nil, false, generatedError(v.Name, value)
+		}
+		return nil, false, nil
+	}
+	return values, true, nil
+}
+
+// composite returns the value of a composite segment, made of the values
+// of its parts, or a sample value if the parts do not fit together.
+func (g *exampleGenerator) composite(seg *pathmatchpb.Segment, c *pathmatchpb.Composite) (string, bool, error) {
+	var b strings.Builder
+	for _, part := range c.Parts {
+		if _, ok := part.Segment.(*pathmatchpb.Segment_Star); ok {
+			b.WriteByte('x')
+			continue
+		}
+		value, ok, err := g.single(part)
+		if !ok || err != nil {
+			return "", ok, err
+		}
+		b.WriteString(value)
+	}
+
+	opts := &match.MatchOptions{CaseInsensitive: g.upper}
+	if value := b.String(); matches(seg, value, opts) {
+		return value, true, nil
+	}
+	for _, value := range samples(seg) {
+		if matches(seg, value, opts) {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (g *exampleGenerator) literal(value string) string {
+	if g.upper {
+		return strings.ToUpper(value)
+	}
+	return value
+}
+
+func generatedError(name, value string) error {
+	return fmt.Errorf("%w: generated %q for variable %q", expand.ErrInvalidValue, value, name)
+}
@@ -0,0 +1,98 @@
+package lang_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/expand"
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+)
+
+func TestExamples(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		n           int
+		opts        lang.ExampleOptions
+		expected    []string
+	}{
+		{templateStr: "/users/{id}", n: 3, expected: []string{"/users/id1", "/users/id2", "/users/id3"}},
+		{templateStr: "/users/{id:int}", n: 2, expected: []string{"/users/1", "/users/2"}},
+		{templateStr: "/users/{id:uuid}", n: 2, expected: []string{"/users/123e4567-e89b-12d3-a456-426614174000"}},
+		{templateStr: "/orders/{id:[0-9]{3}}", n: 1, expected: []string{"/orders/000"}},
+		{templateStr: "/api/(v1|v2)/*", n: 3, expected: []string{"/api/v1/x1", "/api/v2/x2", "/api/v1/x3"}},
+		{templateStr: "/files/{name}.{ext}", n: 1, expected: []string{"/files/name1.ext1"}},
+		{templateStr: "/static", n: 3, expected: []string{"/static"}},
+		{templateStr: "/files/{path=**}", n: 2, expected: []string{"/files/a", "/files/a/b"}},
+		{templateStr: "/v1/{name=shelves/*}:get", n: 1, expected: []string{"/v1/shelves/x1:get"}},
+		{templateStr: "/users/{id}[/profile]", n: 2, expected: []string{"/users/id1/profile", "/users/id2"}},
+		{
+			templateStr: "/users/{id}",
+			n:           2,
+			opts: lang.ExampleOptions{Generators: map[string]func(int) string{
+				"id": func(i int) string { return fmt.Sprintf("user-%d", i*10) },
+			}},
+			expected: []string{"/users/user-0", "/users/user-10"},
+		},
+		{
+			templateStr: "/v1/{name=shelves/*}/books/{book}",
+			n:           1,
+			opts: lang.ExampleOptions{Generators: map[string]func(int) string{
+				"name": func(int) string { return "shelves/7" },
+			}},
+			expected: []string{"/v1/shelves/7/books/book1"},
+		},
+		{
+			templateStr: "/files/{path=**}[/raw]",
+			n:           3,
+			opts:        lang.ExampleOptions{Boundary: true},
+			expected:    []string{"/files", "/files/a/b/c/raw", "/files/a/raw"},
+		},
+		{
+			templateStr: "/Users/{id}:Get",
+			n:           3,
+			opts:        lang.ExampleOptions{Boundary: true, CaseVariants: true},
+			expected:    []string{"/Users/id1:Get", "/Users/id2:Get", "/USERS/id3:GET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl := mustParse(t, tt.templateStr)
+			examples, err := lang.Examples(tmpl, tt.n, &tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, examples)
+
+			opts := &match.MatchOptions{CaseInsensitive: tt.opts.CaseVariants}
+			for _, path := range examples {
+				matched, _, err := match.StrictMatch(tmpl, path, opts)
+				require.NoError(t, err)
+				require.True(t, matched, path)
+			}
+		})
+	}
+}
+
+func TestExamplesInvalidGenerator(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		value       string
+	}{
+		{templateStr: "/users/{id:int}", value: "abc"},
+		{templateStr: "/users/{id}", value: "a/b"},
+		{templateStr: "/users/{id}", value: ""},
+		{templateStr: "/v1/{id=shelves/*}", value: "books/1"},
+		{templateStr: "/files/{id}.json", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr+" "+tt.value, func(t *testing.T) {
+			opts := &lang.ExampleOptions{Generators: map[string]func(int) string{
+				"id": func(int) string { return tt.value },
+			}}
+			_, err := lang.Examples(mustParse(t, tt.templateStr), 1, opts)
+			require.ErrorIs(t, err, expand.ErrInvalidValue)
+		})
+	}
+}
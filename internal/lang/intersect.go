@@ -0,0 +1,608 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/normalize"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotRepresentable is returned by Intersect when no single template
+// matches exactly the paths matched by both templates.
+var ErrNotRepresentable = errors.New("intersection cannot be represented as a template")
+
+const (
+	// maxChains bounds the number of templates without optional groups
+	// a template is expanded into.
+	maxChains = 64
+	// maxSequences bounds the number of alternatives of an intersection.
+	maxSequences = 256
+)
+
+// Intersect returns a template matching exactly the paths matched by both
+// templates, and whether they match a common path. Literals are compared
+// case-sensitively.
+//
+// The intersection is computed segment by segment: '**' takes the
+// segments of the other template, a wildcard or unconstrained variable
+// takes the other segment, and literals and alternations are kept if the
+// other segment matches them, captured by the other segment's variable if
+// it is one. Where both templates have a variable, the variable of a is
+// kept. Optional groups are expanded, and the
+// alternatives of the result are merged back into optional groups.
+//
+// ErrNotRepresentable is returned when the paths in common are not the
+// paths of a single template, such as for "/**/a/**" and "/**/b/**", or
+// when two different constraints or composite segments meet. ok then
+// reports whether the templates are known to match a common path.
+func Intersect(a, b *pathmatchpb.PathTemplate) (*pathmatchpb.PathTemplate, bool, error) {
+	if a == nil || b == nil {
+		return nil, false, errors.New("template cannot be nil")
+	}
+
+	segsA, segsB, verb, ok, err := alignVerbs(a, b)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+	chainsA, err := expandOptional(segsA)
+	if err != nil {
+		return nil, false, err
+	}
+	chainsB, err := expandOptional(segsB)
+	if err != nil {
+		return nil, false, err
+	}
+
+	opts := &match.MatchOptions{}
+	var results [][]*pathmatchpb.Segment
+	for _, ca := range chainsA {
+		for _, cb := range chainsB {
+			in := &intersector{a: flatten(ca), b: flatten(cb), opts: opts, memo: make(map[[2]int][][]part)}
+			sequences := in.intersect(0, 0)
+			if in.tooMany {
+				return nil, true, fmt.Errorf("%w: too many alternatives", ErrNotRepresentable)
+			}
+			for _, seq := range sequences {
+				segs, err := assemble(seq, in.a, in.b)
+				if err != nil {
+					// Whether the templates overlap is not known
+					_, found, werr := Witness(a, b, opts)
+					return nil, found, errors.Join(err, werr)
+				}
+				results = append(results, segs)
+			}
+		}
+	}
+
+	results = simplify(results, opts)
+	switch len(results) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return &pathmatchpb.PathTemplate{Segments: results[0], Verb: verb}, true, nil
+	}
+	return nil, true, fmt.Errorf("%w: the common paths are those of %d templates", ErrNotRepresentable, len(results))
+}
+
+// alignVerbs returns the segments of both templates and the verb of their
+// intersection. A template without a verb matches a path with a verb when
+// its last segment takes the colon and the verb, so the verb of the other
+// template is then moved into its last segment.
+func alignVerbs(a, b *pathmatchpb.PathTemplate) (segsA, segsB []*pathmatchpb.Segment, verb string, ok bool, err error) {
+	segsA, segsB, verb = a.GetSegments(), b.GetSegments(), a.GetVerb()
+	switch {
+	case a.GetVerb() == b.GetVerb():
+	case a.GetVerb() != "" && b.GetVerb() != "":
+		return nil, nil, "", false, nil
+	case a.GetVerb() != "":
+		segsA, err = appendVerb(segsA, a.GetVerb())
+		verb = ""
+	default:
+		segsB, err = appendVerb(segsB, b.GetVerb())
+		verb = ""
+	}
+	return segsA, segsB, verb, err == nil, err
+}
+
+// appendVerb appends ":verb" to the last segment, which must be a single
+// segment matching only non-empty values.
+func appendVerb(segs []*pathmatchpb.Segment, verb string) ([]*pathmatchpb.Segment, error) {
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("%w: verb %q without segments", ErrNotRepresentable, verb)
+	}
+	last := segs[len(segs)-1]
+	suffix := literal(":" + verb)
+
+	var suffixed *pathmatchpb.Segment
+	switch s := last.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		suffixed = literal(s.Literal.Value + ":" + verb)
+	case *pathmatchpb.Segment_Composite:
+		suffixed = composite(slices.Concat(s.Composite.Parts, []*pathmatchpb.Segment{suffix}))
+	case *pathmatchpb.Segment_Alternation:
+		suffixed = composite([]*pathmatchpb.Segment{last, suffix})
+	case *pathmatchpb.Segment_Variable:
+		if !match.HasSubPattern(s.Variable) {
+			suffixed = composite([]*pathmatchpb.Segment{last, suffix})
+		}
+	}
+	if suffixed == nil {
+		return nil, fmt.Errorf("%w: verb %q after %s", ErrNotRepresentable, verb, formatSegment(last))
+	}
+	return slices.Concat(segs[:len(segs)-1], []*pathmatchpb.Segment{suffixed}), nil
+}
+
+// expandOptional returns the templates without optional groups whose
+// paths together are those of segs: each group is both taken and skipped.
+func expandOptional(segs []*pathmatchpb.Segment) ([][]*pathmatchpb.Segment, error) {
+	chains := [][]*pathmatchpb.Segment{nil}
+	for _, seg := range segs {
+		var next [][]*pathmatchpb.Segment
+		if opt := seg.GetOptional(); opt != nil {
+			inner, err := expandOptional(opt.Segments)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range chains {
+				for _, in := range inner {
+					next = append(next, slices.Concat(c, in))
+				}
+				next = append(next, c)
+			}
+		} else {
+			for _, c := range chains {
+				next = append(next, append(slices.Clip(c), seg))
+			}
+		}
+		if len(next) > maxChains {
+			return nil, fmt.Errorf("%w: too many optional groups", ErrNotRepresentable)
+		}
+		chains = next
+	}
+	return chains, nil
+}
+
+// item is a segment of a template without optional groups, in which the
+// variables with a sub-pattern are flattened.
+type item struct {
+	seg   *pathmatchpb.Segment // a single-segment segment, or nil for '**'
+	owner *pathmatchpb.Variable
+}
+
+func flatten(segs []*pathmatchpb.Segment) []item {
+	var items []item
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_DoubleStar:
+			items = append(items, item{})
+		case *pathmatchpb.Segment_Variable:
+			if !match.HasSubPattern(s.Variable) {
+				items = append(items, item{seg: seg})
+				break
+			}
+			for _, sub := range s.Variable.Segments {
+				if _, ok := sub.Segment.(*pathmatchpb.Segment_DoubleStar); ok {
+					items = append(items, item{owner: s.Variable})
+				} else {
+					items = append(items, item{seg: sub, owner: s.Variable})
+				}
+			}
+		default:
+			items = append(items, item{seg: seg})
+		}
+	}
+	return items
+}
+
+// part is a segment of an intersection, with the variables of both
+// templates it belongs to.
+type part struct {
+	seg            *pathmatchpb.Segment // nil for '**'
+	ownerA, ownerB *pathmatchpb.Variable
+	err            error // the segments cannot be intersected
+}
+
+type intersector struct {
+	a, b    []item
+	opts    *match.MatchOptions
+	memo    map[[2]int][][]part
+	tooMany bool
+}
+
+// intersect returns the alternatives of the intersection of a[i:] and b[j:].
+// '**' followed by the rest of a template meets a segment of the other
+// template by either taking it or taking nothing, and meets another '**'
+// by sharing the segments up to where one of them ends.
+func (in *intersector) intersect(i, j int) [][]part {
+	key := [2]int{i, j}
+	if seqs, ok := in.memo[key]; ok {
+		return seqs
+	}
+
+	var seqs [][]part
+	inA, inB := i < len(in.a), j < len(in.b)
+	starA := inA && in.a[i].seg == nil
+	starB := inB && in.b[j].seg == nil
+	switch {
+	case !inA && !inB:
+		seqs = [][]part{{}}
+	case starA && starB:
+		p := part{ownerA: in.a[i].owner, ownerB: in.b[j].owner}
+		seqs = union(prepend(p, in.intersect(i+1, j)), prepend(p, in.intersect(i, j+1)))
+	case starA:
+		seqs = in.intersect(i+1, j)
+		if inB {
+			p := part{seg: in.b[j].seg, ownerA: in.a[i].owner, ownerB: in.b[j].owner}
+			seqs = union(seqs, prepend(p, in.intersect(i, j+1)))
+		}
+	case starB:
+		seqs = in.intersect(i, j+1)
+		if inA {
+			p := part{seg: in.a[i].seg, ownerA: in.a[i].owner, ownerB: in.b[j].owner}
+			seqs = union(seqs, prepend(p, in.intersect(i+1, j)))
+		}
+	case inA && inB:
+		seg, ok, err := intersectSegment(in.a[i].seg, in.b[j].seg, in.opts)
+		if ok || err != nil {
+			p := part{seg: seg, ownerA: in.a[i].owner, ownerB: in.b[j].owner, err: err}
+			seqs = prepend(p, in.intersect(i+1, j+1))
+		}
+	}
+
+	if len(seqs) > maxSequences {
+		in.tooMany = true
+		seqs = seqs[:maxSequences]
+	}
+	in.memo[key] = seqs
+	return seqs
+}
+
+// prepend returns the sequences with p prepended. A '**' is not repeated.
+func prepend(p part, seqs [][]part) [][]part {
+	prepended := make([][]part, len(seqs))
+	for i, seq := range seqs {
+		if p.seg != nil || len(seq) == 0 || seq[0] != p {
+			seq = slices.Concat([]part{p}, seq)
+		}
+		prepended[i] = seq
+	}
+	return prepended
+}
+
+// union returns the sequences of all groups, without duplicates.
+func union(groups ...[][]part) [][]part {
+	var seqs [][]part
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		for _, seq := range group {
+			if key := partsKey(seq); !seen[key] {
+				seen[key] = true
+				seqs = append(seqs, seq)
+			}
+		}
+	}
+	return seqs
+}
+
+func partsKey(seq []part) string {
+	var b strings.Builder
+	for _, p := range seq {
+		fmt.Fprintf(&b, "%p %p ", p.ownerA, p.ownerB)
+		if p.seg == nil {
+			b.WriteString("**")
+		} else {
+			b.WriteString(formatSegment(p.seg))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// intersectSegment returns a single-segment segment matching the values
+// matched by both x and y, or false if there are none.
+func intersectSegment(x, y *pathmatchpb.Segment, opts *match.MatchOptions) (*pathmatchpb.Segment, bool, error) {
+	switch {
+	case isAny(x) && isAny(y):
+		if x.GetVariable() == nil && y.GetVariable() != nil {
+			return y, true, nil
+		}
+		return x, true, nil
+	case isAny(x):
+		if _, ok := literalValues(y); ok {
+			return captured(y, x), true, nil
+		}
+		return renamed(y, x), true, nil
+	case isAny(y):
+		if _, ok := literalValues(x); ok {
+			return captured(x, y), true, nil
+		}
+		return x, true, nil
+	}
+
+	if values, ok := literalValues(x); ok {
+		seg, ok, err := filterValues(values, y, opts)
+		return captured(seg, y), ok, err
+	}
+	if values, ok := literalValues(y); ok {
+		seg, ok, err := filterValues(values, x, opts)
+		return captured(seg, x), ok, err
+	}
+	if sameSegment(x, y) {
+		return x, true, nil
+	}
+	return nil, false, fmt.Errorf("%w: %s and %s", ErrNotRepresentable, formatSegment(x), formatSegment(y))
+}
+
+// isAny reports whether a single-segment segment matches any value.
+func isAny(seg *pathmatchpb.Segment) bool {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Star:
+		return true
+	case *pathmatchpb.Segment_Variable:
+		return !match.HasSubPattern(s.Variable) && s.Variable.GetType() == "" && s.Variable.GetRegex() == ""
+	}
+	return false
+}
+
+// renamed returns seg with the name of the variable any, if both are
+// variables.
+func renamed(seg, any *pathmatchpb.Segment) *pathmatchpb.Segment {
+	v, name := seg.GetVariable(), any.GetVariable().GetName()
+	if v == nil || name == "" {
+		return seg
+	}
+	v = proto.Clone(v).(*pathmatchpb.Variable)
+	v.Name = name
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Variable{Variable: v}}
+}
+
+// captured returns the literal or alternation seg captured by the variable
+// v, as "{id=new}" for "{id}" facing "new", if v is a variable.
+func captured(seg, v *pathmatchpb.Segment) *pathmatchpb.Segment {
+	name := v.GetVariable().GetName()
+	if seg == nil || name == "" {
+		return seg
+	}
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Variable{Variable: &pathmatchpb.Variable{
+		Name:     name,
+		Segments: []*pathmatchpb.Segment{seg},
+	}}}
+}
+
+// literalValues returns the values matched by a literal or alternation.
+func literalValues(seg *pathmatchpb.Segment) ([]string, bool) {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		return []string{s.Literal.Value}, true
+	case *pathmatchpb.Segment_Alternation:
+		return s.Alternation.Values, true
+	}
+	return nil, false
+}
+
+// filterValues returns a literal or an alternation of the values matched
+// by seg.
+func filterValues(values []string, seg *pathmatchpb.Segment, opts *match.MatchOptions) (*pathmatchpb.Segment, bool, error) {
+	var kept []string
+	for _, v := range values {
+		ok, err := match.MatchSegment(seg, v, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			kept = append(kept, v)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return literal(kept[0]), true, nil
+	}
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Alternation{
+		Alternation: &pathmatchpb.Alternation{Values: kept},
+	}}, true, nil
+}
+
+// sameSegment reports whether two single-segment segments match the same
+// values: variables with the same constraint, or equal composites.
+func sameSegment(x, y *pathmatchpb.Segment) bool {
+	vx, vy := x.GetVariable(), y.GetVariable()
+	if vx != nil && vy != nil {
+		return !match.HasSubPattern(vx) && !match.HasSubPattern(vy) &&
+			vx.GetType() == vy.GetType() && vx.GetRegex() == vy.GetRegex()
+	}
+	return proto.Equal(x, y)
+}
+
+// assemble builds the segments of an alternative of an intersection of
+// chains a and b. The parts of a variable with a sub-pattern are wrapped
+// back into it, the variables of a taking precedence.
+func assemble(seq []part, a, b []item) ([]*pathmatchpb.Segment, error) {
+	var (
+		segs    []*pathmatchpb.Segment
+		run     *pathmatchpb.Variable
+		runSegs []*pathmatchpb.Segment
+	)
+	flush := func() {
+		if run != nil {
+			segs = append(segs, &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Variable{Variable: &pathmatchpb.Variable{
+				Name:     run.Name,
+				Segments: runSegs,
+				Type:     run.Type,
+				Regex:    run.Regex,
+			}}})
+		}
+		run, runSegs = nil, nil
+	}
+
+	owned := make(map[*pathmatchpb.Variable]int) // parts wrapped in a variable
+	spans := make(map[*pathmatchpb.Variable]int) // parts coming from a variable
+	for _, p := range seq {
+		if p.err != nil {
+			return nil, p.err
+		}
+		if p.ownerA != nil {
+			spans[p.ownerA]++
+		}
+		if p.ownerB != nil && p.ownerB != p.ownerA {
+			spans[p.ownerB]++
+		}
+
+		seg := p.seg
+		if seg == nil {
+			seg = &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_DoubleStar{DoubleStar: &pathmatchpb.DoubleStar{}}}
+		}
+		owner := p.ownerA
+		if owner == nil {
+			owner = p.ownerB
+		}
+		if owner == nil {
+			flush()
+			segs = append(segs, seg)
+			continue
+		}
+
+		// A sub-pattern cannot hold variables: one capturing a literal
+		// gives it up to the variable of the sub-pattern
+		if v := seg.GetVariable(); v != nil {
+			switch {
+			case isAny(seg):
+				seg = &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Star{}}
+			case match.HasSubPattern(v):
+				seg = v.Segments[0]
+			default:
+				return nil, fmt.Errorf("%w: variable %q inside variable %q", ErrNotRepresentable, v.Name, owner.Name)
+			}
+		}
+		if hasVariable(seg.GetComposite().GetParts()) {
+			return nil, fmt.Errorf("%w: %s inside variable %q", ErrNotRepresentable, formatSegment(seg), owner.Name)
+		}
+		owned[owner]++
+		if owner != run {
+			flush()
+			run = owner
+		}
+		runSegs = append(runSegs, seg)
+	}
+	flush()
+
+	// A constraint holds for the whole value of a variable, so a
+	// constrained variable must be kept whole
+	for _, chain := range [][]item{a, b} {
+		for _, it := range chain {
+			v := it.owner
+			if v == nil || (v.GetType() == "" && v.GetRegex() == "") {
+				continue
+			}
+			if owned[v] == 0 || owned[v] != spans[v] {
+				return nil, fmt.Errorf("%w: constrained variable %q is split", ErrNotRepresentable, v.Name)
+			}
+		}
+	}
+	return segs, nil
+}
+
+func hasVariable(parts []*pathmatchpb.Segment) bool {
+	for _, p := range parts {
+		if p.GetVariable() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// simplify normalizes the alternatives of an intersection, drops those
+// covered by another one and merges the others into optional groups.
+func simplify(results [][]*pathmatchpb.Segment, opts *match.MatchOptions) [][]*pathmatchpb.Segment {
+	for i, r := range results {
+		results[i] = normalize.Normalize(&pathmatchpb.PathTemplate{Segments: r}).Segments
+	}
+
+	var kept [][]*pathmatchpb.Segment
+	for i, r := range results {
+		covered := false
+		for j, other := range results {
+			if i == j {
+				continue
+			}
+			// Of two alternatives covering each other, the first is kept
+			if covers(other, r, opts) && (j < i || !covers(r, other, opts)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, r)
+		}
+	}
+	return mergeOptional(kept)
+}
+
+// mergeOptional merges pairs of alternatives x and y, where y is x with
+// more segments inserted, into x with an optional group of the inserted
+// segments. The longest x is merged first, so that nested groups are
+// built from the inside.
+func mergeOptional(chains [][]*pathmatchpb.Segment) [][]*pathmatchpb.Segment {
+	for {
+		bi, bj, bk := -1, -1, -1
+		for i, x := range chains {
+			for j, y := range chains {
+				if len(y) <= len(x) {
+					continue
+				}
+				k := insertion(x, y)
+				if k < 0 {
+					continue
+				}
+				if bi < 0 || len(x) > len(chains[bi]) || (len(x) == len(chains[bi]) && len(y) < len(chains[bj])) {
+					bi, bj, bk = i, j, k
+				}
+			}
+		}
+		if bi < 0 {
+			return chains
+		}
+
+		x, y := chains[bi], chains[bj]
+		group := &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Optional{
+			Optional: &pathmatchpb.Optional{Segments: y[bk : bk+len(y)-len(x)]},
+		}}
+		chains[bi] = slices.Concat(x[:bk], []*pathmatchpb.Segment{group}, x[bk:])
+		chains = slices.Delete(chains, bj, bj+1)
+	}
+}
+
+// insertion returns the last index at which segments are inserted into x
+// to make y, or -1.
+func insertion(x, y []*pathmatchpb.Segment) int {
+	d := len(y) - len(x)
+	for k := len(x); k >= 0; k-- {
+		if equalSegments(x[:k], y[:k]) && equalSegments(x[k:], y[k+d:]) {
+			return k
+		}
+	}
+	return -1
+}
+
+func equalSegments(x, y []*pathmatchpb.Segment) bool {
+	return slices.EqualFunc(x, y, func(a, b *pathmatchpb.Segment) bool { return proto.Equal(a, b) })
+}
+
+func literal(value string) *pathmatchpb.Segment {
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Literal{Literal: &pathmatchpb.Literal{Value: value}}}
+}
+
+func composite(parts []*pathmatchpb.Segment) *pathmatchpb.Segment {
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Composite{Composite: &pathmatchpb.Composite{Parts: parts}}}
+}
+
+func formatSegment(seg *pathmatchpb.Segment) string {
+	return strings.TrimPrefix(parse.Format(&pathmatchpb.PathTemplate{Segments: []*pathmatchpb.Segment{seg}}), "/")
+}
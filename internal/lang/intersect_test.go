@@ -0,0 +1,135 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/normalize"
+	"github.com/tsdkv/pathmatch/internal/parse"
+)
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string // empty if the templates are disjoint
+	}{
+		{a: "/projects/*/secrets/**", b: "/projects/{p}/**", expected: "/projects/{p}/secrets/**"},
+		{a: "/projects/{p}/**", b: "/projects/*/secrets/**", expected: "/projects/{p}/secrets/**"},
+		{a: "/items/{id}", b: "/items/*", expected: "/items/{id}"},
+		{a: "/items/*", b: "/items/{sku}", expected: "/items/{sku}"},
+		{a: "/items/{id}", b: "/items/{sku}", expected: "/items/{id}"},
+		{a: "/items/{id}", b: "/users/{id}"},
+		{a: "/items/{id}", b: "/items/{id}/edit"},
+		{a: "/items/{id}", b: "/items/new", expected: "/items/{id=new}"},
+		{a: "/items/{id:int}", b: "/items/42", expected: "/items/{id=42}"},
+		{a: "/items/(new|old)", b: "/items/{id}", expected: "/items/{id=(new|old)}"},
+		{a: "/items/{id:int}", b: "/items/new"},
+		{a: "/items/{id}", b: "/items/{sku:int}", expected: "/items/{id:int}"},
+		{a: "/items/{id:int}", b: "/items/{sku:int}", expected: "/items/{id:int}"},
+		{a: "/api/(v1|v2|v3)", b: "/api/(v2|v3|v4)", expected: "/api/(v2|v3)"},
+		{a: "/api/(v1|v2)", b: "/api/(v2|v3)", expected: "/api/v2"},
+		{a: "/files/{name}.json", b: "/files/(a.json|b.yaml)", expected: "/files/a.json"},
+		{a: "/a/**", b: "/**/b", expected: "/a/**/b"},
+		{a: "/a/**", b: "/b/**"},
+		{a: "/**", b: "/x/{y}", expected: "/x/{y}"},
+		{a: "/**", b: "/**", expected: "/**"},
+		{a: "/**/a/**", b: "/**/a/**", expected: "/**/a/**"},
+		{a: "/**/a", b: "/b/**", expected: "/b/**/a"},
+		{a: "/v1/{name=shelves/*}", b: "/v1/shelves/{s}", expected: "/v1/{name=shelves/*}"},
+		{a: "/v1/{name=shelves/*/books/*}", b: "/v1/**/books/{b}", expected: "/v1/{name=shelves/*/books/*}"},
+		{a: "/files/{path=**}", b: "/files/a/**", expected: "/files/{path=a/**}"},
+		{a: "/files/{path=a/**}", b: "/files/{x}/{y}", expected: "/files/{path=a/*}"},
+		{a: "/files/**", b: "/files/{path=**:[a-z/]+}", expected: "/files/{path=**:[a-z/]+}"},
+		{a: "/files/{path=**:[a-z/]+}", b: "/files/a/{b=**}", expected: "/files/{path=a/**:[a-z/]+}"},
+		{a: "/users/{id}[/profile]", b: "/users/*/profile", expected: "/users/{id}/profile"},
+		{a: "/users/{id}[/profile]", b: "/users/*[/{tab}]", expected: "/users/{id}[/{tab=profile}]"},
+		{a: "/reports/{y}[/{m}[/{d}]]", b: "/reports/**", expected: "/reports/{y}[/{m}[/{d}]]"},
+		{a: "/reports/{y}[/{m}]", b: "/reports/{y}/{m}/{d}"},
+		{a: "/v1/{name=books/*}:publish", b: "/v1/books/{id}:publish", expected: "/v1/{name=books/*}:publish"},
+		{a: "/v1/books/{id}:publish", b: "/v1/books/{id}:delete"},
+		{a: "/v1/books/{id}", b: "/v1/books/{b}:publish", expected: "/v1/books/{b}\\:publish"},
+		{a: "/v1/books/1:publish", b: "/v1/books/{id}", expected: "/v1/books/{id=1\\:publish}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			result, ok, err := lang.Intersect(mustParse(t, tt.a), mustParse(t, tt.b))
+			require.NoError(t, err)
+			if tt.expected == "" {
+				require.False(t, ok)
+				require.Nil(t, result)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.expected, parse.Format(result))
+			require.True(t, normalize.Equal(mustParse(t, tt.expected), result))
+		})
+	}
+}
+
+func TestIntersectNotRepresentable(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{a: "/**/a/**", b: "/**/b/**", overlap: true},
+		{a: "/items/{id:int}", b: "/items/{id:[0-9]{3}}", overlap: true},
+		{a: "/items/{id:int}", b: "/items/{id:uuid}"},
+		{a: "/files/{name}.json", b: "/files/{stem}.{ext}", overlap: true},
+		{a: "/files/{path=**:[a-z/]+}", b: "/files/{a=a/**:[a-c/]+}", overlap: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			result, ok, err := lang.Intersect(mustParse(t, tt.a), mustParse(t, tt.b))
+			require.ErrorIs(t, err, lang.ErrNotRepresentable)
+			require.Nil(t, result)
+			require.Equal(t, tt.overlap, ok)
+		})
+	}
+}
+
+// TestIntersectPaths checks that the intersection of each pair of templates
+// matches exactly the paths both templates match.
+func TestIntersectPaths(t *testing.T) {
+	templates := []string{
+		"/a/{x}", "/a/b", "/a/*/c", "/a/**", "/**/c", "/**", "/{x}/{y}/{z}",
+		"/a[/b]", "/a[/b[/c]]", "/a/(b|c)", "/{x}[/c]", "/a/{p=b/**}", "/{p=**}/c",
+		"/a/{x:int}", "/a/b:run", "/a/{x}:run", "/a/{x}.json",
+	}
+	paths := []string{
+		"/", "/a", "/b", "/a/b", "/a/c", "/a/1", "/a/b/c", "/a/x/c", "/a/b/b",
+		"/b/a/c", "/a/b/c/d", "/c", "/a/b:run", "/a/1:run", "/a/b.json", "/a/b/c/c",
+	}
+	opts := &match.MatchOptions{}
+
+	for _, sa := range templates {
+		for _, sb := range templates {
+			a, b := mustParse(t, sa), mustParse(t, sb)
+			result, ok, err := lang.Intersect(a, b)
+			if err != nil {
+				require.ErrorIs(t, err, lang.ErrNotRepresentable, "%s %s", sa, sb)
+				continue
+			}
+			if ok {
+				_, err := parse.ParseTemplate(parse.Format(result))
+				require.NoError(t, err, "%s %s", sa, sb)
+			}
+
+			for _, path := range paths {
+				inA, _, err := match.StrictMatch(a, path, opts)
+				require.NoError(t, err)
+				inB, _, err := match.StrictMatch(b, path, opts)
+				require.NoError(t, err)
+				inResult := false
+				if ok {
+					inResult, _, err = match.StrictMatch(result, path, opts)
+					require.NoError(t, err)
+				}
+				require.Equal(t, inA && inB, inResult, "%s and %s: %s, intersection %s", sa, sb, path, parse.Format(result))
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package lang treats templates as languages: the sets of paths they match.
+// It answers questions about several templates at once, such as whether
+// two templates match a common path.
+package lang
+
+import (
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// nfa is a nondeterministic automaton whose transitions are labelled with L.
+// State 0 is the start state.
+type nfa[L any] struct {
+	edges  [][]transition[L]
+	eps    [][]int // epsilon transitions
+	accept int
+}
+
+type transition[L any] struct {
+	label L
+	to    int
+}
+
+func (n *nfa[L]) newState() int {
+	n.edges = append(n.edges, nil)
+	n.eps = append(n.eps, nil)
+	return len(n.edges) - 1
+}
+
+func (n *nfa[L]) add(from, to int, label L) {
+	n.edges[from] = append(n.edges[from], transition[L]{label: label, to: to})
+}
+
+func (n *nfa[L]) addEps(from, to int) {
+	n.eps[from] = append(n.eps[from], to)
+}
+
+// segmentNFA is an automaton over path segments. Its transitions are
+// labelled with single-segment template segments.
+type segmentNFA = nfa[*pathmatchpb.Segment]
+
+// anySegment labels the transitions of '**', which take any segment.
+var anySegment = &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Star{Star: &pathmatchpb.Star{}}}
+
+// newSegmentNFA builds the automaton of the paths matched by segs.
+// The constraints of sub-pattern variables are not represented.
+func newSegmentNFA(segs []*pathmatchpb.Segment) *segmentNFA {
+	n := &segmentNFA{}
+	n.newState()
+	n.accept = addSegments(n, segs, 0)
+	return n
+}
+
+// addSegments adds the transitions of segs from state from,
+// and returns the state reached after them.
+func addSegments(n *segmentNFA, segs []*pathmatchpb.Segment, from int) int {
+	for _, seg := range segs {
+		from = addSegment(n, seg, from)
+	}
+	return from
+}
+
+func addSegment(n *segmentNFA, seg *pathmatchpb.Segment, from int) int {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_DoubleStar:
+		loop := n.newState()
+		n.addEps(from, loop)
+		n.add(loop, loop, anySegment)
+		return loop
+
+	case *pathmatchpb.Segment_Variable:
+		if len(s.Variable.Segments) > 0 {
+			return addSegments(n, s.Variable.Segments, from)
+		}
+
+	case *pathmatchpb.Segment_Optional:
+		// A fresh end state, so that skipping the group cannot enter
+		// the loops of its last segment
+		end := n.newState()
+		n.addEps(addSegments(n, s.Optional.Segments, from), end)
+		n.addEps(from, end)
+		return end
+	}
+
+	to := n.newState()
+	n.add(from, to, seg)
+	return to
+}
+
+// witness looks for a word accepted by both a and b. The meet function
+// returns a symbol matching both labels, if there is one. The search is
+// breadth-first over pairs of states, so it ends on any input.
+func witness[L, V any](a, b *nfa[L], meet func(la, lb L) (V, bool)) ([]V, bool) {
+	type pair struct{ a, b int }
+	type step struct {
+		prev   pair
+		symbol V
+		eps    bool
+	}
+
+	start := pair{0, 0}
+	steps := map[pair]step{start: {}}
+	queue := []pair{start}
+	visit := func(p, next pair, s step) {
+		if _, seen := steps[next]; seen {
+			return
+		}
+		s.prev = p
+		steps[next] = s
+		queue = append(queue, next)
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		if p.a == a.accept && p.b == b.accept {
+			var word []V
+			for p != start {
+				s := steps[p]
+				if !s.eps {
+					word = append(word, s.symbol)
+				}
+				p = s.prev
+			}
+			for i, j := 0, len(word)-1; i < j; i, j = i+1, j-1 {
+				word[i], word[j] = word[j], word[i]
+			}
+			return word, true
+		}
+
+		for _, to := range a.eps[p.a] {
+			visit(p, pair{to, p.b}, step{eps: true})
+		}
+		for _, to := range b.eps[p.b] {
+			visit(p, pair{p.a, to}, step{eps: true})
+		}
+		for _, ta := range a.edges[p.a] {
+			for _, tb := range b.edges[p.b] {
+				if symbol, ok := meet(ta.label, tb.label); ok {
+					visit(p, pair{ta.to, tb.to}, step{symbol: symbol})
+				}
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,236 @@
+package lang
+
+import (
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/vartype"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// genericSamples are tried last for segments without a better sample.
+var genericSamples = []string{"x", "1", "a.b"}
+
+// commonValue returns a path segment value matched by both segments.
+// Candidates are the sample values of each segment, a value built from
+// the characters both segments accept, and a few generic values.
+// It may miss a common value when constraints make it rare.
+func commonValue(a, b *pathmatchpb.Segment, opts *match.MatchOptions) (string, bool) {
+	candidates := slices.Concat(samples(a), samples(b))
+	if chars, ok := witness(newCharNFA(a), newCharNFA(b), meetChars(opts.CaseInsensitive)); ok {
+		candidates = append(candidates, string(chars))
+	}
+	candidates = append(candidates, genericSamples...)
+
+	for _, value := range candidates {
+		if matches(a, value, opts) && matches(b, value, opts) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func matches(seg *pathmatchpb.Segment, value string, opts *match.MatchOptions) bool {
+	if value == "" || strings.Contains(value, "/") {
+		return false
+	}
+	ok, err := match.MatchSegment(seg, value, opts)
+	return ok && err == nil
+}
+
+// samples returns values of a single path segment that a template segment
+// is likely to match: its literals and sample values of its variable
+// constraints. It returns nil for segments matching any value.
+func samples(seg *pathmatchpb.Segment) []string {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		return []string{s.Literal.Value}
+	case *pathmatchpb.Segment_Alternation:
+		return s.Alternation.Values
+	case *pathmatchpb.Segment_Variable:
+		return variableSamples(s.Variable)
+	case *pathmatchpb.Segment_Composite:
+		var b strings.Builder
+		for _, part := range s.Composite.Parts {
+			switch p := part.Segment.(type) {
+			case *pathmatchpb.Segment_Literal:
+				b.WriteString(p.Literal.Value)
+			case *pathmatchpb.Segment_Alternation:
+				b.WriteString(p.Alternation.Values[0])
+			case *pathmatchpb.Segment_Variable:
+				if values := variableSamples(p.Variable); len(values) > 0 {
+					b.WriteString(values[0])
+				} else {
+					b.WriteString("x")
+				}
+			}
+		}
+		return []string{b.String()}
+	}
+	return nil
+}
+
+// variableSamples returns sample values satisfying the constraints of a
+// variable, if it has any.
+func variableSamples(v *pathmatchpb.Variable) []string {
+	var values []string
+	if example, ok := vartype.Example(v.GetType()); ok {
+		values = append(values, example)
+	}
+	if v.GetRegex() != "" {
+		values = append(values, regexSamples(v.GetRegex())...)
+	}
+	return values
+}
+
+// regexSamples returns strings matched by a regular expression: the
+// shortest one it builds, and one taking each repetition once.
+func regexSamples(expr string) []string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	re = re.Simplify()
+
+	var shortest, repeated strings.Builder
+	sampleRegex(&shortest, re, false)
+	sampleRegex(&repeated, re, true)
+	return []string{shortest.String(), repeated.String()}
+}
+
+// sampleRegex writes a string matched by re. Repetitions are taken the
+// fewest times, or at least once if repeat is set.
+func sampleRegex(b *strings.Builder, re *syntax.Regexp, repeat bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(classSample(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('x')
+	case syntax.OpCapture:
+		sampleRegex(b, re.Sub[0], repeat)
+	case syntax.OpStar, syntax.OpQuest:
+		if repeat {
+			sampleRegex(b, re.Sub[0], repeat)
+		}
+	case syntax.OpPlus:
+		sampleRegex(b, re.Sub[0], repeat)
+	case syntax.OpRepeat:
+		n := re.Min
+		if repeat && n == 0 && re.Max != 0 {
+			n = 1
+		}
+		for range n {
+			sampleRegex(b, re.Sub[0], repeat)
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			sampleRegex(b, sub, repeat)
+		}
+	case syntax.OpAlternate:
+		sampleRegex(b, re.Sub[0], repeat)
+	}
+}
+
+// classSample returns a rune of a character class, preferring letters
+// and digits. ranges holds pairs of inclusive bounds.
+func classSample(ranges []rune) rune {
+	for _, preferred := range [][2]rune{{'a', 'z'}, {'0', '9'}, {'A', 'Z'}} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			lo, hi := max(ranges[i], preferred[0]), min(ranges[i+1], preferred[1])
+			if lo <= hi {
+				return lo
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] > ' ' && ranges[i] != '/' {
+			return ranges[i]
+		}
+	}
+	if len(ranges) == 0 {
+		return 'x'
+	}
+	return ranges[0]
+}
+
+// charLabel labels the transitions of a character automaton:
+// either a single byte or any byte.
+type charLabel struct {
+	any bool
+	c   byte
+}
+
+// newCharNFA builds the automaton of the values of a single path segment
+// matched by seg, ignoring variable constraints.
+func newCharNFA(seg *pathmatchpb.Segment) *nfa[charLabel] {
+	n := &nfa[charLabel]{}
+	n.newState()
+	n.accept = addChars(n, seg, 0, true)
+	return n
+}
+
+// addChars adds the transitions of seg from state from, and returns the
+// state reached after them. Variables and wildcards take at least one
+// character if nonEmpty is set, as they do for whole segments.
+func addChars(n *nfa[charLabel], seg *pathmatchpb.Segment, from int, nonEmpty bool) int {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		for i := 0; i < len(s.Literal.Value); i++ {
+			to := n.newState()
+			n.add(from, to, charLabel{c: s.Literal.Value[i]})
+			from = to
+		}
+		return from
+
+	case *pathmatchpb.Segment_Alternation:
+		end := n.newState()
+		for _, v := range s.Alternation.Values {
+			lit := &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Literal{Literal: &pathmatchpb.Literal{Value: v}}}
+			n.addEps(addChars(n, lit, from, nonEmpty), end)
+		}
+		return end
+
+	case *pathmatchpb.Segment_Composite:
+		for _, part := range s.Composite.Parts {
+			_, isStar := part.Segment.(*pathmatchpb.Segment_Star)
+			from = addChars(n, part, from, !isStar)
+		}
+		return from
+
+	case *pathmatchpb.Segment_Star, *pathmatchpb.Segment_Variable:
+		loop := n.newState()
+		if nonEmpty {
+			n.add(from, loop, charLabel{any: true})
+		} else {
+			n.addEps(from, loop)
+		}
+		n.add(loop, loop, charLabel{any: true})
+		return loop
+	}
+
+	// Not a single-segment segment: nothing matches
+	return n.newState()
+}
+
+// meetChars returns the meet function of two character labels.
+func meetChars(caseInsensitive bool) func(a, b charLabel) (byte, bool) {
+	return func(a, b charLabel) (byte, bool) {
+		switch {
+		case a.any && b.any:
+			return 'x', true
+		case a.any:
+			return b.c, true
+		case b.any:
+			return a.c, true
+		case a.c == b.c:
+			return a.c, true
+		case caseInsensitive && strings.EqualFold(string(a.c), string(b.c)):
+			return a.c, true
+		}
+		return 0, false
+	}
+}
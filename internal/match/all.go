@@ -0,0 +1,60 @@
+package match
+
+import (
+	"slices"
+
+	"github.com/tsdkv/pathmatch/internal/rank"
+	"github.com/tsdkv/pathmatch/internal/utils"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Candidate is a template of a set that matched a path.
+type Candidate struct {
+	// Index is the position of the template in the set.
+	Index int
+	Vars  map[string]string
+	// Score is the specificity rank of the template among the candidates:
+	// 0 for the most specific ones, 1 for the next ones, and so on.
+	// Equally specific candidates have the same score.
+	Score int
+	// N is the number of path segments matched.
+	N int
+}
+
+// MatchAll matches every template against the path, either the whole path
+// or a prefix of it, and returns the templates that matched, from the most
+// to the least specific. Equally specific templates keep their order.
+func MatchAll(templates []*pathmatchpb.PathTemplate, path string, opts *MatchOptions, prefix bool) ([]Candidate, error) {
+	segments := utils.Split(path)
+
+	var candidates []Candidate
+	for i, tmpl := range templates {
+		var matched bool
+		var n int
+		var vars map[string]string
+		var err error
+		if prefix {
+			matched, n, vars, err = MatchPrefix(tmpl, segments, opts)
+		} else {
+			matched, vars, err = StrictMatch(tmpl, path, opts)
+			n = len(segments)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			candidates = append(candidates, Candidate{Index: i, Vars: vars, N: n})
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		return rank.Compare(templates[a.Index], templates[b.Index])
+	})
+	for i := 1; i < len(candidates); i++ {
+		candidates[i].Score = candidates[i-1].Score
+		if rank.Compare(templates[candidates[i-1].Index], templates[candidates[i].Index]) != 0 {
+			candidates[i].Score++
+		}
+	}
+	return candidates, nil
+}
@@ -0,0 +1,50 @@
+package match_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func TestMatchAll(t *testing.T) {
+	input := []string{
+		"/users/**",
+		"/users/{id}",
+		"/users/{name}",
+		"/users/me",
+		"/users/{id}/posts",
+		"/groups/{id}",
+	}
+	templates := make([]*pmpb.PathTemplate, len(input))
+	for i, s := range input {
+		tmpl, err := parse.ParseTemplate(s)
+		require.NoError(t, err)
+		templates[i] = tmpl
+	}
+
+	candidates, err := match.MatchAll(templates, "/users/me", &match.MatchOptions{}, false)
+	require.NoError(t, err)
+	require.Equal(t, []match.Candidate{
+		{Index: 3, Vars: map[string]string{}, Score: 0, N: 2},
+		{Index: 1, Vars: map[string]string{"id": "me"}, Score: 1, N: 2},
+		{Index: 2, Vars: map[string]string{"name": "me"}, Score: 1, N: 2},
+		{Index: 0, Vars: map[string]string{}, Score: 2, N: 2},
+	}, candidates)
+
+	candidates, err = match.MatchAll(templates, "/users/me/posts", &match.MatchOptions{}, true)
+	require.NoError(t, err)
+	require.Equal(t, []match.Candidate{
+		{Index: 3, Vars: map[string]string{}, Score: 0, N: 2},
+		{Index: 1, Vars: map[string]string{"id": "me"}, Score: 1, N: 2},
+		{Index: 2, Vars: map[string]string{"name": "me"}, Score: 1, N: 2},
+		{Index: 4, Vars: map[string]string{"id": "me"}, Score: 2, N: 3},
+		{Index: 0, Vars: map[string]string{}, Score: 3, N: 3},
+	}, candidates)
+
+	candidates, err = match.MatchAll(templates, "/other", &match.MatchOptions{}, false)
+	require.NoError(t, err)
+	require.Empty(t, candidates)
+}
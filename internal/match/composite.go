@@ -0,0 +1,102 @@
+package match
+
+import (
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// capture is a variable value captured while matching.
+type capture struct {
+	name  string
+	value string
+	// isDefault is set for the default value of a variable in a skipped group.
+	isDefault bool
+}
+
+// matchComposite matches a single path segment against the parts of a
+// composite segment. Each variable takes the longest non-empty value that
+// lets the remaining parts match, which makes the split deterministic.
+// A wildcard part ('*') likewise takes the longest, possibly empty, run of
+// characters. Captured variables are appended to caps.
+func matchComposite(parts []*pathmatchpb.Segment, segment string, opts *MatchOptions, caps []capture) ([]capture, bool, error) {
+	if len(parts) == 0 {
+		return caps, segment == "", nil
+	}
+
+	switch p := parts[0].Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		n := len(p.Literal.Value)
+		if n > len(segment) || !compareStrings(p.Literal.Value, segment[:n], opts.CaseInsensitive) {
+			return caps, false, nil
+		}
+		return matchComposite(parts[1:], segment[n:], opts, caps)
+
+	case *pathmatchpb.Segment_Alternation:
+		// Try each alternative as a prefix, in template order
+		for _, v := range p.Alternation.Values {
+			n := len(v)
+			if n > len(segment) || !compareStrings(v, segment[:n], opts.CaseInsensitive) {
+				continue
+			}
+			rest, ok, err := matchComposite(parts[1:], segment[n:], opts, caps)
+			if err != nil || ok {
+				return rest, ok, err
+			}
+		}
+		return caps, false, nil
+
+	case *pathmatchpb.Segment_Star:
+		for end := len(segment); end >= 0; end-- {
+			if len(parts) > 1 && !literalPrefixAt(parts[1], segment, end, opts) {
+				continue
+			}
+			rest, ok, err := matchComposite(parts[1:], segment[end:], opts, caps)
+			if err != nil || ok {
+				return rest, ok, err
+			}
+		}
+		return caps, false, nil
+
+	case *pathmatchpb.Segment_Variable:
+		// Try the longest value first and shrink it until the rest matches.
+		for end := len(segment); end > 0; end-- {
+			if len(parts) > 1 && !literalPrefixAt(parts[1], segment, end, opts) {
+				continue
+			}
+			value := segment[:end]
+			ok, err := checkVariable(p.Variable, value)
+			if err != nil {
+				return caps, false, err
+			}
+			if !ok {
+				continue
+			}
+			rest, ok, err := matchComposite(parts[1:], segment[end:], opts, append(caps, capture{name: p.Variable.Name, value: value}))
+			if err != nil || ok {
+				return rest, ok, err
+			}
+		}
+		return caps, false, nil
+	}
+	return caps, false, nil
+}
+
+// literalPrefixAt reports whether the literal part, or one of the alternatives
+// of an alternation part, occurs in segment at position i.
+func literalPrefixAt(part *pathmatchpb.Segment, segment string, i int, opts *MatchOptions) bool {
+	var values []string
+	switch p := part.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		values = []string{p.Literal.Value}
+	case *pathmatchpb.Segment_Alternation:
+		values = p.Alternation.Values
+	default:
+		return true
+	}
+	for _, v := range values {
+		n := len(v)
+		if i+n <= len(segment) && compareStrings(v, segment[i:i+n], opts.CaseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/tsdkv/pathmatch/internal/vartype"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// regexCache holds compiled variable regexes keyed by their source, so that
+// every template expression is compiled once rather than on each match.
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+// compileRegex returns the compiled, fully anchored form of expr.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(`^(?:` + expr + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+	actual, _ := regexCache.LoadOrStore(expr, re)
+	return actual.(*regexp.Regexp), nil
+}
+
+// checkVariable reports whether a captured value satisfies the type and
+// regex constraints of the variable.
+func checkVariable(v *pathmatchpb.Variable, value string) (bool, error) {
+	ok, err := vartype.Check(v.GetType(), value)
+	if !ok || err != nil {
+		return false, err
+	}
+	if v.GetRegex() == "" {
+		return true, nil
+	}
+	re, err := compileRegex(v.GetRegex())
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
@@ -2,6 +2,7 @@ package match
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/tsdkv/pathmatch/internal/utils"
 	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
@@ -10,17 +11,26 @@ import (
 type MatchOptions struct {
 	CaseInsensitive   bool
 	KeepFirstVariable bool
+	// Unescape sets how percent-encoded path segments are decoded.
+	Unescape UnescapingMode
+	// DecodeSlashes decodes "%2F" in multi-segment captures with UnescapeAll.
+	DecodeSlashes bool
 }
 
 func StrictMatch(template *pathmatchpb.PathTemplate, path string, opts *MatchOptions) (matched bool, vars map[string]string, err error) {
-	pathSegments := utils.Split(path)
-
-	pathIdx := 0
-	matched, pathIdx, vars, err = Match(template, pathSegments, opts)
+	if verb := template.GetVerb(); verb != "" {
+		// The verb is split off the last path segment before matching
+		var ok bool
+		if path, ok = trimVerb(path, verb, opts.CaseInsensitive); !ok {
+			return false, nil, nil
+		}
+	}
 
-	// If we matched the template, check if we consumed all path segments
-	matched = matched && pathIdx == len(pathSegments)
+	pathSegments := utils.Split(path)
 
+	// The whole path must be consumed, so the matcher keeps backtracking
+	// until it finds a match that ends at the last path segment.
+	matched, _, vars, err = match(template, pathSegments, opts, true)
 	if !matched {
 		vars = nil // Clear vars if not matched
 	}
@@ -28,106 +38,301 @@ func StrictMatch(template *pathmatchpb.PathTemplate, path string, opts *MatchOpt
 	return
 }
 
+// Match matches the template against a prefix of pathSegments.
+// It returns whether the template matched, the number of path segments
+// consumed, and the captured variables.
+//
+// Optional groups and '**' are greedy: the first match found consumes as
+// many segments as possible.
 func Match(template *pathmatchpb.PathTemplate, pathSegments []string, opts *MatchOptions) (bool, int, map[string]string, error) {
+	return match(template, pathSegments, opts, false)
+}
+
+// MatchSegment reports whether a template segment matches exactly one
+// path segment with the given value.
+func MatchSegment(segment *pathmatchpb.Segment, value string, opts *MatchOptions) (bool, error) {
+	m := &matcher{path: []string{value}, opts: opts, varStart: -1}
+	return m.matchSegment(segment, 0, func(pathIdx int) (bool, error) {
+		return pathIdx == 1, nil
+	})
+}
+
+// MatchPrefix matches the template against a prefix of pathSegments, like
+// Match. A template with a custom verb matches a prefix whose last segment
+// ends with the verb; the longest such prefix is tried first.
+func MatchPrefix(template *pathmatchpb.PathTemplate, pathSegments []string, opts *MatchOptions) (bool, int, map[string]string, error) {
+	verb := template.GetVerb()
+	if verb == "" {
+		return match(template, pathSegments, opts, false)
+	}
+
+	for n := len(pathSegments); n > 0; n-- {
+		last, ok := trimVerb(pathSegments[n-1], verb, opts.CaseInsensitive)
+		if !ok {
+			continue
+		}
+		prefix := append(pathSegments[:n-1:n-1], last)
+		matched, _, vars, err := match(template, prefix, opts, true)
+		if matched || err != nil {
+			return matched, n, vars, err
+		}
+	}
+	return false, 0, nil, nil
+}
+
+func match(template *pathmatchpb.PathTemplate, pathSegments []string, opts *MatchOptions, full bool) (bool, int, map[string]string, error) {
 	if template == nil {
 		return false, 0, nil, errors.New("template cannot be nil")
 	}
 
-	if len(pathSegments) == 0 {
-		return len(template.Segments) == 0, 0, nil, nil
+	single, multi, err := decodeSegments(pathSegments, opts)
+	if err != nil {
+		return false, 0, nil, err
 	}
 
-	vars := make(map[string]string, len(template.Segments))
+	m := &matcher{path: single, multi: multi, opts: opts, varStart: -1}
+	end := 0
+	matched, err := m.matchSeq(template.Segments, 0, 0, func(pathIdx int) (bool, error) {
+		if full && pathIdx != len(pathSegments) {
+			return false, nil
+		}
+		end = pathIdx
+		return true, nil
+	})
+	if !matched || err != nil {
+		return false, 0, nil, err
+	}
 
-	templateIdx := 0
-	pathIdx := 0
+	return true, end, m.vars(), nil
+}
 
-	for templateIdx < len(template.Segments) && pathIdx < len(pathSegments) {
-		segment := template.Segments[templateIdx]
-		pathSegment := pathSegments[pathIdx]
+// cont is called with the path index reached after a part of the template
+// has matched. It reports whether the rest of the template matched too.
+type cont func(pathIdx int) (bool, error)
 
-		switch s := segment.Segment.(type) {
-		case *pathmatchpb.Segment_Literal:
-			if !compareStrings(s.Literal.Value, pathSegment, opts.CaseInsensitive) {
-				return false, 0, nil, nil
-			}
-			templateIdx++
-			pathIdx++
-
-		case *pathmatchpb.Segment_Star:
-			// Star matches any single segment
-			templateIdx++
-			pathIdx++
-
-		case *pathmatchpb.Segment_DoubleStar:
-			// Double star matches remaining segments
-			if templateIdx != len(template.Segments)-1 {
-				return false, 0, nil, errors.New("double star must be the last segment")
+// matcher holds the state of a single backtracking match.
+type matcher struct {
+	path []string
+	// multi holds the path segments used for multi-segment captures,
+	// when they are decoded differently from path.
+	multi []string
+	opts  *MatchOptions
+	// caps holds the variables captured so far, in template order.
+	// On backtracking it is truncated back to its previous length.
+	caps []capture
+	// varStart is the path index where the enclosing variable capture
+	// started, or -1 outside of a variable's sub-pattern.
+	varStart int
+	// failed records the states known not to match. It is created on the
+	// first '**' or optional group, the only segments that backtrack.
+	failed map[state]struct{}
+}
+
+// state identifies a point of the match. The rest of the match from this
+// point depends only on these fields, not on the variables captured so far,
+// so a state that failed once will always fail. Remembering failed states
+// bounds the work to a polynomial in the template and path lengths, even
+// with several '**' segments.
+type state struct {
+	pos      **pathmatchpb.Segment // position of the segment in its sequence
+	pathIdx  int
+	varStart int
+}
+
+// vars builds the variable map from the captures, applying the
+// variable merging policy. Default values only fill in variables that
+// were not captured from the path.
+func (m *matcher) vars() map[string]string {
+	vars := make(map[string]string, len(m.caps))
+	for _, c := range m.caps {
+		if c.isDefault {
+			continue
+		}
+		if _, exists := vars[c.name]; exists && m.opts.KeepFirstVariable {
+			continue
+		}
+		vars[c.name] = c.value
+	}
+	for _, c := range m.caps {
+		if _, exists := vars[c.name]; c.isDefault && !exists {
+			vars[c.name] = c.value
+		}
+	}
+	return vars
+}
+
+// matchSeq matches segs[i:] against the path starting at pathIdx,
+// calling k once all of segs has matched.
+func (m *matcher) matchSeq(segs []*pathmatchpb.Segment, i, pathIdx int, k cont) (bool, error) {
+	if i == len(segs) {
+		return k(pathIdx)
+	}
+	next := func(pathIdx int) (bool, error) {
+		return m.matchSeq(segs, i+1, pathIdx, k)
+	}
+
+	st := state{&segs[i], pathIdx, m.varStart}
+	if _, ok := m.failed[st]; ok {
+		return false, nil
+	}
+
+	mark := len(m.caps)
+	ok, err := m.matchSegment(segs[i], pathIdx, next)
+	if !ok {
+		m.caps = m.caps[:mark]
+		if err == nil && m.failed != nil {
+			m.failed[st] = struct{}{}
+		}
+	}
+	return ok, err
+}
+
+// matchSegment matches a single template segment at pathIdx, calling k
+// with the path index after it. Segments that can match in several ways
+// try each way in turn until k succeeds.
+func (m *matcher) matchSegment(segment *pathmatchpb.Segment, pathIdx int, k cont) (bool, error) {
+	switch s := segment.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		if pathIdx >= len(m.path) || !compareStrings(s.Literal.Value, m.path[pathIdx], m.opts.CaseInsensitive) {
+			return false, nil
+		}
+		return k(pathIdx + 1)
+
+	case *pathmatchpb.Segment_Star:
+		// Star matches any single segment
+		if pathIdx >= len(m.path) {
+			return false, nil
+		}
+		return k(pathIdx + 1)
+
+	case *pathmatchpb.Segment_DoubleStar:
+		// Double star matches zero or more segments. It is greedy: the longest
+		// run is tried first, then it gives back one segment at a time.
+		m.trackFailures()
+		mark := len(m.caps)
+		for end := len(m.path); end >= pathIdx; end-- {
+			if ok, err := k(end); ok || err != nil {
+				return ok, err
 			}
-			pathIdx = len(pathSegments) // Move path index to the end
-			return true, pathIdx, vars, nil
+			m.caps = m.caps[:mark]
+		}
+		return false, nil
+
+	case *pathmatchpb.Segment_Alternation:
+		if pathIdx >= len(m.path) || !matchAlternation(s.Alternation, m.path[pathIdx], m.opts.CaseInsensitive) {
+			return false, nil
+		}
+		return k(pathIdx + 1)
+
+	case *pathmatchpb.Segment_Variable:
+		return m.matchVariable(s.Variable, pathIdx, k)
+
+	case *pathmatchpb.Segment_Composite:
+		if pathIdx >= len(m.path) {
+			return false, nil
+		}
+		caps, ok, err := matchComposite(s.Composite.Parts, m.path[pathIdx], m.opts, m.caps)
+		if !ok || err != nil {
+			return false, err
+		}
+		m.caps = caps
+		return k(pathIdx + 1)
+
+	case *pathmatchpb.Segment_Optional:
+		// Try to match the group first, then try skipping it
+		m.trackFailures()
+		mark := len(m.caps)
+		ok, err := m.matchSeq(s.Optional.Segments, 0, pathIdx, k)
+		if ok || err != nil {
+			return ok, err
+		}
+		m.caps = m.caps[:mark]
+		m.addDefaults(s.Optional.Segments)
+		return k(pathIdx)
+	}
+
+	return false, errors.New("unexpected segment type in template")
+}
+
+func (m *matcher) matchVariable(v *pathmatchpb.Variable, pathIdx int, k cont) (bool, error) {
+	if !HasSubPattern(v) {
+		// Simple variable: {var} or {var=*}
+		if pathIdx >= len(m.path) {
+			return false, nil
+		}
+		value := m.path[pathIdx]
+		if ok, err := checkVariable(v, value); !ok || err != nil {
+			return false, err
+		}
+		m.caps = append(m.caps, capture{name: v.Name, value: value})
+		return k(pathIdx + 1)
+	}
+
+	// Variable with pattern: {var=pattern}
+	// The value is the joined path segments matched by the pattern.
+	for _, seg := range v.Segments {
+		if _, ok := seg.Segment.(*pathmatchpb.Segment_Variable); ok {
+			return false, errors.New("nested variables in patterns are not allowed")
+		}
+	}
+	start, outer := pathIdx, m.varStart
+	m.varStart = start
+	defer func() { m.varStart = outer }()
+
+	return m.matchSeq(v.Segments, 0, pathIdx, func(end int) (bool, error) {
+		value := m.join(start, end)
+		if ok, err := checkVariable(v, value); !ok || err != nil {
+			return false, err
+		}
+		m.caps = append(m.caps, capture{name: v.Name, value: value})
+
+		// The rest of the template is matched outside of the variable
+		m.varStart = outer
+		defer func() { m.varStart = start }()
+		return k(end)
+	})
+}
 
+// HasSubPattern reports whether v captures the joined segments matched by
+// a sub-pattern, with a leading '/'. "{x=*}" matches a single segment as
+// "{x}" does, and captures it the same way.
+func HasSubPattern(v *pathmatchpb.Variable) bool {
+	if len(v.Segments) != 1 {
+		return len(v.Segments) > 0
+	}
+	_, ok := v.Segments[0].Segment.(*pathmatchpb.Segment_Star)
+	return !ok
+}
+
+// addDefaults captures the default values of the variables in a skipped group.
+func (m *matcher) addDefaults(segs []*pathmatchpb.Segment) {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
 		case *pathmatchpb.Segment_Variable:
-			if s.Variable.Segments == nil {
-				// Simple variable: {var}
-				vars[s.Variable.Name] = pathSegment
-				templateIdx++
-				pathIdx++
-			} else {
-				// Variable with pattern: {var=pattern}
-				// Check if remaining path segments match the variable pattern
-				varValue := []string{}
-				for i := range s.Variable.Segments {
-					switch seg := s.Variable.Segments[i].Segment.(type) {
-					case *pathmatchpb.Segment_Literal:
-						if pathIdx >= len(pathSegments) || !compareStrings(seg.Literal.Value, pathSegments[pathIdx], opts.CaseInsensitive) {
-							return false, 0, nil, nil
-						}
-						varValue = append(varValue, seg.Literal.Value)
-						pathIdx++
-					case *pathmatchpb.Segment_DoubleStar:
-						// Double star in variable pattern matches all remaining segments
-						if i != len(s.Variable.Segments)-1 && templateIdx != len(template.Segments)-1 {
-							return false, 0, nil, errors.New("double star must be the last segment in variable pattern")
-						}
-						// Collect all remaining segments
-						varValue = append(varValue, pathSegments[pathIdx:]...)
-						vars[s.Variable.Name] = utils.Join(varValue...)
-						pathIdx = len(pathSegments) // Move to the end of path segments
-						return true, pathIdx, vars, nil
-					case *pathmatchpb.Segment_Star:
-						// Star in variable pattern matches any single segment
-						if pathIdx < len(pathSegments) {
-							varValue = append(varValue, pathSegments[pathIdx])
-							pathIdx++
-						} else {
-							return false, 0, nil, nil
-						}
-					case *pathmatchpb.Segment_Variable:
-						return false, 0, nil, errors.New("nested variables in patterns are not allowed")
-					default:
-						return false, 0, nil, errors.New("unexpected segment type in variable pattern")
-					}
-
-				}
-				templateIdx++
-
-				_, ok := vars[s.Variable.Name]
-				if !ok {
-					vars[s.Variable.Name] = utils.Join(varValue...)
-				} else if !opts.KeepFirstVariable {
-					// If the variable already exists and we're not keeping the first value,
-					// overwrite it with the new value.
-					vars[s.Variable.Name] = utils.Join(varValue...)
-				}
+			if s.Variable.DefaultValue != "" {
+				m.caps = append(m.caps, capture{name: s.Variable.Name, value: s.Variable.DefaultValue, isDefault: true})
 			}
+		case *pathmatchpb.Segment_Composite:
+			m.addDefaults(s.Composite.Parts)
+		case *pathmatchpb.Segment_Optional:
+			m.addDefaults(s.Optional.Segments)
 		}
 	}
+}
 
-	// Check if we've matched all segments
-	if templateIdx != len(template.Segments) {
-		return false, 0, nil, nil
+// join returns the value of a multi-segment capture of path[start:end].
+func (m *matcher) join(start, end int) string {
+	segments := m.path
+	if m.multi != nil {
+		segments = m.multi
 	}
+	return "/" + strings.Join(segments[start:end], "/")
+}
 
-	return true, pathIdx, vars, nil
+// trackFailures starts recording failed states, once the template is known
+// to contain segments that backtrack.
+func (m *matcher) trackFailures() {
+	if m.failed == nil {
+		m.failed = make(map[state]struct{})
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tsdkv/pathmatch/internal/match"
 	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/internal/utils"
 )
 
 func equalVars(a, b map[string]string) bool {
@@ -71,6 +72,18 @@ func TestMatch(t *testing.T) {
 			expectedMatch: true,
 			expectedVars:  map[string]string{"var": "to"},
 		},
+		{
+			templateStr:   "/path/{var=*}",
+			path:          "/path/to",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"var": "to"},
+		},
+		{
+			templateStr:   "/path/{var=*:int}",
+			path:          "/path/42",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"var": "42"},
+		},
 		{
 			templateStr:   "/**",
 			path:          "/path/to/resource",
@@ -108,6 +121,424 @@ func TestMatch(t *testing.T) {
 				CaseInsensitive: true,
 			},
 		},
+		{
+			templateStr:   "/users/{id:int}",
+			path:          "/users/42",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "42"},
+		},
+		{
+			templateStr:   "/users/{id:int}",
+			path:          "/users/alice",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/users/{id:uuid}",
+			path:          "/users/123e4567-e89b-12d3-a456-426614174000",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{
+			templateStr:   "/users/{id:uuid}",
+			path:          "/users/123e4567",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/events/{day:date}",
+			path:          "/events/2024-02-29",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"day": "2024-02-29"},
+		},
+		{
+			templateStr:   "/events/{day:date}",
+			path:          "/events/2023-02-29",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/orders/{id:[0-9]+}",
+			path:          "/orders/123",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "123"},
+		},
+		{
+			templateStr:   "/orders/{id:[0-9]+}",
+			path:          "/orders/123abc",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/orders/{id:[a-z]{2}-[0-9]+}",
+			path:          "/orders/ab-7",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "ab-7"},
+		},
+		{
+			templateStr:   "/files/{path=**:/docs/.*[.]md}",
+			path:          "/files/docs/guide/intro.md",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"path": "/docs/guide/intro.md"},
+		},
+		{
+			templateStr:   "/files/{path=**:/docs/.*[.]md}",
+			path:          "/files/docs/guide/intro.txt",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/files/{name}.{ext}",
+			path:          "/files/archive.tar.gz",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "archive.tar", "ext": "gz"},
+		},
+		{
+			templateStr:   "/files/{name}.{ext}",
+			path:          "/files/README",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/files/{name}.{ext}",
+			path:          "/files/.bashrc",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/api/v{major:int}/items",
+			path:          "/api/v2/items",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"major": "2"},
+		},
+		{
+			templateStr:   "/api/v{major:int}/items",
+			path:          "/api/vX/items",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/reports/{id}-summary",
+			path:          "/reports/a-b-summary",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "a-b"},
+		},
+		{
+			templateStr:   "/reports/{id:[a-z]+}-{kind}",
+			path:          "/reports/ab-cd-ef",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "ab", "kind": "cd-ef"},
+		},
+		{
+			templateStr:   "/Reports/{id}-Summary",
+			path:          "/reports/7-SUMMARY",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7"},
+			matchOpts: match.MatchOptions{
+				CaseInsensitive: true,
+			},
+		},
+		{
+			templateStr:   "/v1/{name=books/*}:publish",
+			path:          "/v1/books/1:publish",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "/books/1"},
+		},
+		{
+			templateStr:   "/v1/{name=books/*}:publish",
+			path:          "/v1/books/1",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/v1/{name=books/*}:publish",
+			path:          "/v1/books/1:archive",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/v1/books/{id}:publish",
+			path:          "/v1/books/:publish",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/v1/books/{id}:publish",
+			path:          "/v1/books/1:PUBLISH",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "1"},
+			matchOpts: match.MatchOptions{
+				CaseInsensitive: true,
+			},
+		},
+		{
+			templateStr:   "/v1/books/{id}",
+			path:          "/v1/books/1:publish",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "1:publish"},
+		},
+		{
+			templateStr:   "/data/**",
+			path:          "/data",
+			expectedMatch: true,
+		},
+		{
+			templateStr:   "/users/{id}[/profile]",
+			path:          "/users/7",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7"},
+		},
+		{
+			templateStr:   "/users/{id}[/profile]",
+			path:          "/users/7/profile",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7"},
+		},
+		{
+			templateStr:   "/users/{id}[/profile]",
+			path:          "/users/7/settings",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/reports/{year}[/{month}[/{day}]]",
+			path:          "/reports/2024",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"year": "2024"},
+		},
+		{
+			templateStr:   "/reports/{year}[/{month}[/{day}]]",
+			path:          "/reports/2024/05",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"year": "2024", "month": "05"},
+		},
+		{
+			templateStr:   "/reports/{year}[/{month}[/{day}]]",
+			path:          "/reports/2024/05/17",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"year": "2024", "month": "05", "day": "17"},
+		},
+		{
+			templateStr:   "/reports/{year}[/{month}[/{day}]]",
+			path:          "/reports/2024/05/17/extra",
+			expectedMatch: false,
+		},
+		{
+			// The group is skipped when matching it would leave nothing for {name}
+			templateStr:   "/items[/{kind}]/{name}",
+			path:          "/items/book",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "book"},
+		},
+		{
+			templateStr:   "/items[/{kind}]/{name}",
+			path:          "/items/paper/book",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"kind": "paper", "name": "book"},
+		},
+		{
+			templateStr:   "/{id}/{id}",
+			path:          "/first/second",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "first"},
+			matchOpts: match.MatchOptions{
+				KeepFirstVariable: true,
+			},
+		},
+		{
+			templateStr:   "/api/(v1|v2)/items",
+			path:          "/api/v2/items",
+			expectedMatch: true,
+		},
+		{
+			templateStr:   "/api/(v1|v2)/items",
+			path:          "/api/v3/items",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/{kind=(users|groups)}/{id}",
+			path:          "/groups/42",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"kind": "/groups", "id": "42"},
+		},
+		{
+			templateStr:   "/{kind=(users|groups)}/{id}",
+			path:          "/GROUPS/42",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"kind": "/GROUPS", "id": "42"},
+			matchOpts: match.MatchOptions{
+				CaseInsensitive: true,
+			},
+		},
+		{
+			templateStr:   "/files/{name}.(json|yaml)",
+			path:          "/files/config.yaml",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "config"},
+		},
+		{
+			templateStr:   "/files/{name}.(json|yaml)",
+			path:          "/files/config.toml",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/repo/**/config.yaml",
+			path:          "/repo/config.yaml",
+			expectedMatch: true,
+		},
+		{
+			templateStr:   "/repo/**/config.yaml",
+			path:          "/repo/a/b/config.yaml",
+			expectedMatch: true,
+		},
+		{
+			templateStr:   "/repo/**/config.yaml",
+			path:          "/repo/a/b/config.yml",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/src/**/test/*.go",
+			path:          "/src/pkg/util/test/main_test.go",
+			expectedMatch: true,
+		},
+		{
+			templateStr:   "/src/**/test/*.go",
+			path:          "/src/pkg/test/main.rs",
+			expectedMatch: false,
+		},
+		{
+			templateStr:   "/tenants/{t}/**/settings",
+			path:          "/tenants/acme/a/settings/b/settings",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"t": "acme"},
+		},
+		{
+			// '**' is greedy, so the first capture takes as much as it can
+			templateStr:   "/a/{x=**}/b/{y=**}",
+			path:          "/a/1/b/2/b/3",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"x": "/1/b/2", "y": "/3"},
+		},
+		{
+			templateStr:   "/{dir=**/docs}/{file=*.md}",
+			path:          "/x/docs/y/docs/readme.md",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"dir": "/x/docs/y/docs", "file": "/readme.md"},
+		},
+		{
+			templateStr:   "/files/{name}",
+			path:          "/files/a%20b",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "a%20b"},
+		},
+		{
+			templateStr:   "/files/{name}",
+			path:          "/files/a%20b%2Fc",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "a b%2Fc"},
+			matchOpts: match.MatchOptions{
+				Unescape: match.UnescapeAllExceptReserved,
+			},
+		},
+		{
+			templateStr:   "/files/{name}",
+			path:          "/files/a%20b%2Fc",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "a b/c"},
+			matchOpts: match.MatchOptions{
+				Unescape: match.UnescapeAll,
+			},
+		},
+		{
+			templateStr:   "/files/{path=**}",
+			path:          "/files/a%2Fb/c%20d",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"path": "/a%2Fb/c d"},
+			matchOpts: match.MatchOptions{
+				Unescape: match.UnescapeAll,
+			},
+		},
+		{
+			templateStr:   "/files/{path=**}",
+			path:          "/files/a%2Fb/c%20d",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"path": "/a/b/c d"},
+			matchOpts: match.MatchOptions{
+				Unescape:      match.UnescapeAll,
+				DecodeSlashes: true,
+			},
+		},
+		{
+			templateStr:   "/my files/{name}",
+			path:          "/my%20files/x",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "x"},
+			matchOpts: match.MatchOptions{
+				Unescape: match.UnescapeAllExceptReserved,
+			},
+		},
+		{
+			templateStr:   `/a\/b/{name}`,
+			path:          "/a%2Fb/x",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"name": "x"},
+			matchOpts: match.MatchOptions{
+				Unescape: match.UnescapeAll,
+			},
+		},
+		{
+			// Captures of the longer runs tried first are dropped
+			templateStr:   "/files/{path=**}/raw",
+			path:          "/files/a/b/raw",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"path": "/a/b"},
+			matchOpts: match.MatchOptions{
+				KeepFirstVariable: true,
+			},
+		},
+		{
+			templateStr:   "/export/{id}/{format?:json}",
+			path:          "/export/7",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7", "format": "json"},
+		},
+		{
+			templateStr:   "/export/{id}/{format?=json}",
+			path:          "/export/7/xml",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7", "format": "xml"},
+		},
+		{
+			templateStr:   "/export/{id}/{format?}",
+			path:          "/export/7",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"id": "7"},
+		},
+		{
+			templateStr:   "/reports/{year}[/{month?=01}[/{day?=01}]]",
+			path:          "/reports/2024",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"year": "2024", "month": "01", "day": "01"},
+		},
+		{
+			// A default never overrides a captured value
+			templateStr:   "/{x}/{x?=default}",
+			path:          "/captured",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"x": "captured"},
+		},
+		{
+			templateStr:   "/{x?=default}/{x}",
+			path:          "/captured",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"x": "captured"},
+			matchOpts: match.MatchOptions{
+				KeepFirstVariable: true,
+			},
+		},
+		{
+			templateStr:   "/{x}/{x?=default}",
+			path:          "/first/second",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"x": "first"},
+			matchOpts: match.MatchOptions{
+				KeepFirstVariable: true,
+			},
+		},
+		{
+			templateStr:   "/{x}/{x?=default}",
+			path:          "/first/second",
+			expectedMatch: true,
+			expectedVars:  map[string]string{"x": "second"},
+		},
 		{
 			templateStr:   "/default/case/InSeNSitIvE/unmatched",
 			path:          "/default/cAse/iNsEnSiTiVe/Unmatched",
@@ -127,3 +558,97 @@ func TestMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchBacktrackingIsBounded(t *testing.T) {
+	// Without remembering failed states this needs exponential time
+	template, err := parse.ParseTemplate("/**/a/**/a/**/a/**/a/**/a/**/a/**/a/**/b")
+	require.NoError(t, err)
+
+	segments := make([]string, 200)
+	for i := range segments {
+		segments[i] = "a"
+	}
+	matched, _, _, err := match.Match(template, segments, &match.MatchOptions{})
+	require.NoError(t, err)
+	require.False(t, matched)
+}
+
+func TestMatchMalformedPath(t *testing.T) {
+	template, err := parse.ParseTemplate("/files/{name}")
+	require.NoError(t, err)
+
+	_, _, err = match.StrictMatch(template, "/files/a%2", &match.MatchOptions{Unescape: match.UnescapeAll})
+	require.ErrorIs(t, err, match.ErrMalformedPath)
+
+	// Without unescaping the path is taken as is
+	matched, _, err := match.StrictMatch(template, "/files/a%2", &match.MatchOptions{})
+	require.NoError(t, err)
+	require.True(t, matched)
+}
+
+func TestMatchPrefix(t *testing.T) {
+	tests := []struct {
+		templateStr   string
+		path          string
+		expectedMatch bool
+		expectedN     int
+		expectedVars  map[string]string
+	}{
+		{
+			templateStr:   "/tenants/{tenant}",
+			path:          "/tenants/acme/users/1",
+			expectedMatch: true,
+			expectedN:     2,
+			expectedVars:  map[string]string{"tenant": "acme"},
+		},
+		{
+			templateStr:   "/tenants/{tenant}",
+			path:          "/tenants/acme",
+			expectedMatch: true,
+			expectedN:     2,
+			expectedVars:  map[string]string{"tenant": "acme"},
+		},
+		{
+			templateStr: "/tenants/{tenant}",
+			path:        "/users/acme",
+		},
+		{
+			templateStr:   "/files/{path=**}",
+			path:          "/files/a/b",
+			expectedMatch: true,
+			expectedN:     3,
+			expectedVars:  map[string]string{"path": "/a/b"},
+		},
+		{
+			templateStr:   "/docs[/{page}]",
+			path:          "/docs/intro/setup",
+			expectedMatch: true,
+			expectedN:     2,
+			expectedVars:  map[string]string{"page": "intro"},
+		},
+		{
+			templateStr:   "/v1/{name}:publish",
+			path:          "/v1/book:publish/more",
+			expectedMatch: true,
+			expectedN:     2,
+			expectedVars:  map[string]string{"name": "book"},
+		},
+		{
+			templateStr: "/v1/{name}:publish",
+			path:        "/v1/book/more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr+"_"+tt.path, func(t *testing.T) {
+			template, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+
+			matched, n, vars, err := match.MatchPrefix(template, utils.Split(tt.path), &match.MatchOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedMatch, matched)
+			require.Equal(t, tt.expectedN, n)
+			require.True(t, equalVars(vars, tt.expectedVars), "expected vars to be %v, got %v", tt.expectedVars, vars)
+		})
+	}
+}
@@ -0,0 +1,645 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/vartype"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Program is a template compiled into a flat list of instructions.
+// Matching a path with a Program gives the same result as StrictMatch, but
+// the template is validated once, and matches reuse the storage of a
+// Captures value instead of allocating.
+type Program struct {
+	insts []inst
+	// names are the variable names of the template, one capture slot each.
+	names []string
+	opts  MatchOptions
+	verb  string
+	// backtracks is set if the program has '**' or optional groups.
+	backtracks bool
+}
+
+type opcode uint8
+
+const (
+	opLiteral     opcode = iota // a segment equal to str
+	opAlternation               // a segment equal to one of strs
+	opStar                      // any segment
+	opVariable                  // any segment, captured into slot
+	opComposite                 // a segment matching parts
+	opDoubleStar                // any number of segments, as many as possible
+	opSplit                     // the group up to x, or nothing with defaults
+	opSubStart                  // the start of a sub-pattern capture
+	opSubEnd                    // the end of a sub-pattern capture into slot
+	opMatch                     // the end of the template
+)
+
+type inst struct {
+	op    opcode
+	str   string
+	strs  []string
+	slot  int
+	check *constraint
+	// parts are the instructions matching the parts of a composite segment.
+	parts []inst
+	// x is the instruction after the group of an opSplit.
+	x int
+	// defaults are the default values of the variables of an opSplit group.
+	defaults []record
+}
+
+// constraint holds the resolved type and regex constraints of a variable.
+type constraint struct {
+	typ vartype.CheckFunc
+	re  *regexp.Regexp
+}
+
+func (c *constraint) ok(value string) bool {
+	if c == nil {
+		return true
+	}
+	if c.typ != nil && !c.typ(value) {
+		return false
+	}
+	return c.re == nil || c.re.MatchString(value)
+}
+
+// Compile compiles a template for matching whole paths with opts.
+// It returns an error if the template is invalid, such as one referring
+// to an unknown variable type.
+func Compile(template *pathmatchpb.PathTemplate, opts *MatchOptions) (*Program, error) {
+	if template == nil {
+		return nil, errors.New("template cannot be nil")
+	}
+	p := &Program{opts: *opts, verb: template.GetVerb()}
+	if err := p.compile(template.Segments, false); err != nil {
+		return nil, err
+	}
+	p.insts = append(p.insts, inst{op: opMatch})
+	return p, nil
+}
+
+func (p *Program) compile(segs []*pathmatchpb.Segment, inVariable bool) error {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Literal:
+			p.insts = append(p.insts, inst{op: opLiteral, str: s.Literal.Value})
+
+		case *pathmatchpb.Segment_Alternation:
+			p.insts = append(p.insts, inst{op: opAlternation, strs: s.Alternation.Values})
+
+		case *pathmatchpb.Segment_Star:
+			p.insts = append(p.insts, inst{op: opStar})
+
+		case *pathmatchpb.Segment_DoubleStar:
+			p.backtracks = true
+			p.insts = append(p.insts, inst{op: opDoubleStar})
+
+		case *pathmatchpb.Segment_Variable:
+			if inVariable {
+				return errors.New("nested variables in patterns are not allowed")
+			}
+			in, err := p.variable(s.Variable)
+			if err != nil {
+				return err
+			}
+			if !HasSubPattern(s.Variable) {
+				p.insts = append(p.insts, in)
+				continue
+			}
+			p.insts = append(p.insts, inst{op: opSubStart})
+			if err := p.compile(s.Variable.Segments, true); err != nil {
+				return err
+			}
+			in.op = opSubEnd
+			p.insts = append(p.insts, in)
+
+		case *pathmatchpb.Segment_Composite:
+			parts, err := p.compileParts(s.Composite.Parts)
+			if err != nil {
+				return err
+			}
+			p.insts = append(p.insts, inst{op: opComposite, parts: parts})
+
+		case *pathmatchpb.Segment_Optional:
+			p.backtracks = true
+			split := len(p.insts)
+			p.insts = append(p.insts, inst{op: opSplit, defaults: p.defaults(s.Optional.Segments, nil)})
+			if err := p.compile(s.Optional.Segments, inVariable); err != nil {
+				return err
+			}
+			p.insts[split].x = len(p.insts)
+
+		default:
+			return errors.New("unexpected segment type in template")
+		}
+	}
+	return nil
+}
+
+func (p *Program) compileParts(parts []*pathmatchpb.Segment) ([]inst, error) {
+	insts := make([]inst, 0, len(parts))
+	for _, part := range parts {
+		switch s := part.Segment.(type) {
+		case *pathmatchpb.Segment_Literal:
+			insts = append(insts, inst{op: opLiteral, str: s.Literal.Value})
+		case *pathmatchpb.Segment_Alternation:
+			insts = append(insts, inst{op: opAlternation, strs: s.Alternation.Values})
+		case *pathmatchpb.Segment_Star:
+			insts = append(insts, inst{op: opStar})
+		case *pathmatchpb.Segment_Variable:
+			if len(s.Variable.Segments) > 0 {
+				return nil, errors.New("variables with patterns cannot be part of a composite segment")
+			}
+			in, err := p.variable(s.Variable)
+			if err != nil {
+				return nil, err
+			}
+			insts = append(insts, in)
+		default:
+			return nil, errors.New("unexpected part type in composite segment")
+		}
+	}
+	return insts, nil
+}
+
+// variable returns the opVariable instruction of v, resolving its constraints.
+func (p *Program) variable(v *pathmatchpb.Variable) (inst, error) {
+	in := inst{op: opVariable, slot: p.slot(v.Name)}
+	if v.GetType() == "" && v.GetRegex() == "" {
+		return in, nil
+	}
+
+	in.check = &constraint{}
+	if v.GetType() != "" {
+		check, ok := vartype.Lookup(v.GetType())
+		if !ok {
+			return inst{}, fmt.Errorf("%w: %q", vartype.ErrUnknownType, v.GetType())
+		}
+		in.check.typ = check
+	}
+	if v.GetRegex() != "" {
+		re, err := compileRegex(v.GetRegex())
+		if err != nil {
+			return inst{}, err
+		}
+		in.check.re = re
+	}
+	return in, nil
+}
+
+// slot returns the capture slot of a variable name.
+func (p *Program) slot(name string) int {
+	for i, n := range p.names {
+		if n == name {
+			return i
+		}
+	}
+	p.names = append(p.names, name)
+	return len(p.names) - 1
+}
+
+// defaults appends the default values of the variables in segs to records.
+func (p *Program) defaults(segs []*pathmatchpb.Segment, records []record) []record {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Variable:
+			if s.Variable.DefaultValue != "" {
+				records = append(records, record{slot: p.slot(s.Variable.Name), value: s.Variable.DefaultValue, isDefault: true})
+			}
+		case *pathmatchpb.Segment_Composite:
+			records = p.defaults(s.Composite.Parts, records)
+		case *pathmatchpb.Segment_Optional:
+			records = p.defaults(s.Optional.Segments, records)
+		}
+	}
+	return records
+}
+
+// MatchInto matches the whole path and stores the captured variables in c.
+// The storage of c is reused, so that once it has grown to fit the template
+// and the path, matching does not allocate. Only captures that cannot be
+// taken from the path as is allocate: a sub-pattern capture over repeated
+// slashes, and percent-decoded segments.
+func (p *Program) MatchInto(path string, c *Captures) (bool, error) {
+	c.reset(p)
+
+	if p.verb != "" {
+		var ok bool
+		if path, ok = trimVerb(path, p.verb, p.opts.CaseInsensitive); !ok {
+			return false, nil
+		}
+	}
+	c.split(path)
+	if err := c.decode(&p.opts); err != nil {
+		return false, err
+	}
+
+	if !p.run(c, 0, 0) {
+		return false, nil
+	}
+	c.collect(p.opts.KeepFirstVariable)
+	return true, nil
+}
+
+// run runs the program from instruction pc at path segment seg.
+// It reports whether the rest of the template matched the rest of the path.
+func (p *Program) run(c *Captures, pc, seg int) bool {
+	for {
+		in := &p.insts[pc]
+		switch in.op {
+		case opLiteral:
+			if seg >= len(c.segs) || !compareStrings(in.str, c.segment(seg), p.opts.CaseInsensitive) {
+				return false
+			}
+
+		case opAlternation:
+			if seg >= len(c.segs) || !p.matchValues(in.strs, c.segment(seg)) {
+				return false
+			}
+
+		case opStar:
+			if seg >= len(c.segs) {
+				return false
+			}
+
+		case opVariable:
+			if seg >= len(c.segs) {
+				return false
+			}
+			value := c.segment(seg)
+			if !in.check.ok(value) {
+				return false
+			}
+			c.log = append(c.log, record{slot: in.slot, value: value})
+
+		case opComposite:
+			if seg >= len(c.segs) || !p.matchParts(c, in.parts, c.segment(seg)) {
+				return false
+			}
+
+		case opDoubleStar, opSplit:
+			return p.branch(c, pc, seg)
+
+		case opSubStart:
+			outer := c.varStart
+			c.varStart = seg
+			ok := p.run(c, pc+1, seg)
+			c.varStart = outer
+			return ok
+
+		case opSubEnd:
+			start := c.varStart
+			value := c.join(start, seg)
+			if !in.check.ok(value) {
+				return false
+			}
+			c.log = append(c.log, record{slot: in.slot, value: value})
+
+			// The rest of the template is matched outside of the variable
+			c.varStart = -1
+			ok := p.run(c, pc+1, seg)
+			c.varStart = start
+			return ok
+
+		case opMatch:
+			return seg == len(c.segs)
+		}
+
+		// The instruction took a single segment
+		pc++
+		seg++
+	}
+}
+
+// branch runs the instructions that can match in several ways, trying
+// each way in turn. Failed states are remembered, as by the matcher.
+func (p *Program) branch(c *Captures, pc, seg int) bool {
+	st := progState{pc, seg, c.varStart}
+	if _, failed := c.failed[st]; failed {
+		return false
+	}
+
+	in := &p.insts[pc]
+	mark := len(c.log)
+	if in.op == opDoubleStar {
+		for end := len(c.segs); end >= seg; end-- {
+			if p.run(c, pc+1, end) {
+				return true
+			}
+			c.log = c.log[:mark]
+		}
+	} else {
+		// Try to match the group first, then try skipping it
+		if p.run(c, pc+1, seg) {
+			return true
+		}
+		c.log = append(c.log[:mark], in.defaults...)
+		if p.run(c, in.x, seg) {
+			return true
+		}
+		c.log = c.log[:mark]
+	}
+
+	c.failed[st] = struct{}{}
+	return false
+}
+
+// matchParts matches a path segment against the parts of a composite
+// segment, splitting it as matchComposite does.
+func (p *Program) matchParts(c *Captures, parts []inst, s string) bool {
+	if len(parts) == 0 {
+		return s == ""
+	}
+
+	in := &parts[0]
+	mark := len(c.log)
+	switch in.op {
+	case opLiteral:
+		n := len(in.str)
+		if n > len(s) || !compareStrings(in.str, s[:n], p.opts.CaseInsensitive) {
+			return false
+		}
+		return p.matchParts(c, parts[1:], s[n:])
+
+	case opAlternation:
+		for _, v := range in.strs {
+			n := len(v)
+			if n > len(s) || !compareStrings(v, s[:n], p.opts.CaseInsensitive) {
+				continue
+			}
+			if p.matchParts(c, parts[1:], s[n:]) {
+				return true
+			}
+			c.log = c.log[:mark]
+		}
+
+	case opStar:
+		for end := len(s); end >= 0; end-- {
+			if len(parts) > 1 && !p.prefixAt(&parts[1], s, end) {
+				continue
+			}
+			if p.matchParts(c, parts[1:], s[end:]) {
+				return true
+			}
+			c.log = c.log[:mark]
+		}
+
+	case opVariable:
+		for end := len(s); end > 0; end-- {
+			if len(parts) > 1 && !p.prefixAt(&parts[1], s, end) {
+				continue
+			}
+			value := s[:end]
+			if !in.check.ok(value) {
+				continue
+			}
+			c.log = append(c.log, record{slot: in.slot, value: value})
+			if p.matchParts(c, parts[1:], s[end:]) {
+				return true
+			}
+			c.log = c.log[:mark]
+		}
+	}
+	return false
+}
+
+// prefixAt reports whether a literal or alternation part occurs in s at i.
+func (p *Program) prefixAt(in *inst, s string, i int) bool {
+	switch in.op {
+	case opLiteral:
+		n := len(in.str)
+		return i+n <= len(s) && compareStrings(in.str, s[i:i+n], p.opts.CaseInsensitive)
+	case opAlternation:
+		for _, v := range in.strs {
+			n := len(v)
+			if i+n <= len(s) && compareStrings(v, s[i:i+n], p.opts.CaseInsensitive) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (p *Program) matchValues(values []string, s string) bool {
+	for _, v := range values {
+		if compareStrings(v, s, p.opts.CaseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Captures holds the variables captured by a Program. A Captures value can
+// be reused from one match to the next, and by different programs; its
+// storage grows as needed and is kept.
+type Captures struct {
+	names  []string
+	values []string
+	set    []bool
+
+	// Scratch storage of a match
+	path     string
+	segs     []span
+	decoded  []string // decoded segments, if decoding
+	multi    []string // segments of multi-segment captures, if decoded differently
+	decoding bool
+	log      []record
+	varStart int
+	failed   map[progState]struct{}
+}
+
+// span is the position of a segment in the path.
+type span struct {
+	start, end int
+}
+
+// record is a captured value, as capture is for the matcher.
+type record struct {
+	slot      int
+	value     string
+	isDefault bool
+}
+
+type progState struct {
+	pc, seg, varStart int
+}
+
+// Len returns the number of variables of the template last matched.
+func (c *Captures) Len() int {
+	return len(c.names)
+}
+
+// Name returns the name of the i-th variable, in template order.
+func (c *Captures) Name(i int) string {
+	return c.names[i]
+}
+
+// Value returns the value of the i-th variable, and whether it was captured.
+func (c *Captures) Value(i int) (string, bool) {
+	return c.values[i], c.set[i]
+}
+
+// Get returns the value of the named variable, and whether it was captured.
+func (c *Captures) Get(name string) (string, bool) {
+	for i, n := range c.names {
+		if n == name {
+			return c.values[i], c.set[i]
+		}
+	}
+	return "", false
+}
+
+// Map returns the captured variables in a new map.
+func (c *Captures) Map() map[string]string {
+	vars := make(map[string]string, len(c.names))
+	for i, name := range c.names {
+		if c.set[i] {
+			vars[name] = c.values[i]
+		}
+	}
+	return vars
+}
+
+func (c *Captures) reset(p *Program) {
+	c.names = p.names
+	c.values = grow(c.values, len(p.names))
+	c.set = grow(c.set, len(p.names))
+	clear(c.values)
+	clear(c.set)
+
+	c.log = c.log[:0]
+	c.varStart = -1
+	if c.failed != nil {
+		clear(c.failed)
+	} else if p.backtracks {
+		c.failed = make(map[progState]struct{})
+	}
+}
+
+func grow[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// split splits the path into segments, as utils.Split does.
+func (c *Captures) split(path string) {
+	c.path = path
+	c.segs = c.segs[:0]
+	for i := 0; i < len(path); {
+		for i < len(path) && path[i] == '/' {
+			i++
+		}
+		start := i
+		for i < len(path) && path[i] != '/' {
+			i++
+		}
+		if i > start {
+			c.segs = append(c.segs, span{start, i})
+		}
+	}
+}
+
+// decode decodes the segments as decodeSegments does.
+func (c *Captures) decode(opts *MatchOptions) error {
+	var keep, keepMulti func(byte) bool
+	switch opts.Unescape {
+	case UnescapeNone:
+		c.decoding = false
+		return nil
+	case UnescapeAllExceptReserved:
+		keep = isReserved
+	case UnescapeAll:
+		if !opts.DecodeSlashes {
+			keepMulti = isSlash
+		}
+	default:
+		return fmt.Errorf("unknown unescaping mode %d", opts.Unescape)
+	}
+
+	c.decoding = true
+	c.decoded, c.multi = c.decoded[:0], c.multi[:0]
+	for _, sp := range c.segs {
+		raw := c.path[sp.start:sp.end]
+		s, err := unescape(raw, keep)
+		if err != nil {
+			return err
+		}
+		c.decoded = append(c.decoded, s)
+		if keepMulti != nil {
+			if s, err = unescape(raw, keepMulti); err != nil {
+				return err
+			}
+			c.multi = append(c.multi, s)
+		}
+	}
+	return nil
+}
+
+func (c *Captures) segment(i int) string {
+	if c.decoding {
+		return c.decoded[i]
+	}
+	return c.path[c.segs[i].start:c.segs[i].end]
+}
+
+// join returns the value of a multi-segment capture of segments
+// [start, end), as the matcher does. It is a slice of the path when the
+// segments are unchanged by decoding and separated by single slashes.
+func (c *Captures) join(start, end int) string {
+	if start == end {
+		return "/"
+	}
+
+	segments := c.decoded
+	if len(c.multi) > 0 {
+		segments = c.multi
+	}
+	inPlace := c.segs[start].start > 0
+	for i := start; i < end && inPlace; i++ {
+		if c.decoding && len(segments[i]) != c.segs[i].end-c.segs[i].start {
+			inPlace = false // changed by decoding
+		}
+		if i > start && c.segs[i].start != c.segs[i-1].end+1 {
+			inPlace = false // repeated slashes
+		}
+	}
+	if inPlace {
+		return c.path[c.segs[start].start-1 : c.segs[end-1].end]
+	}
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		b.WriteByte('/')
+		if c.decoding {
+			b.WriteString(segments[i])
+		} else {
+			b.WriteString(c.segment(i))
+		}
+	}
+	return b.String()
+}
+
+// collect sets the values of the variables from the captures, applying the
+// variable merging policy as matcher.vars does.
+func (c *Captures) collect(keepFirst bool) {
+	for _, r := range c.log {
+		if r.isDefault || (keepFirst && c.set[r.slot]) {
+			continue
+		}
+		c.values[r.slot], c.set[r.slot] = r.value, true
+	}
+	for _, r := range c.log {
+		if r.isDefault && !c.set[r.slot] {
+			c.values[r.slot], c.set[r.slot] = r.value, true
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package match_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func TestProgramAgreesWithMatch(t *testing.T) {
+	templates := []string{
+		"/users/{id}",
+		"/users/{id}/posts/{post}",
+		"/users/{id=*}/posts/{post=*:int}",
+		"/files/{path=**}",
+		"/files/{path=**}/raw",
+		"/v1/{name=shelves/*/books/*}",
+		"/v1/{name=shelves/*}:publish",
+		"/a/**/b/**/c",
+		"/{x}/{x}",
+		"/files/{name}.{ext}",
+		"/files/*.(json|yaml)",
+		"/api/(v1|v2)/{rest=**}",
+		"/docs[/{page}[/{section}]]",
+		"/export/{id}/{format?=json}",
+		"/{x}/{x?=default}",
+		"/items/{id:int}",
+		"/items/{sku:[a-z]{3}}",
+		"/Mixed/{Case}",
+		"/",
+		"/**",
+	}
+	paths := []string{
+		"/",
+		"/users/alice",
+		"/users/alice/posts/1",
+		"/users//alice",
+		"/files/a/b/c",
+		"/files//a//b/raw",
+		"/files/a%2Fb/c%20d",
+		"/files/report.pdf",
+		"/files/config.yaml",
+		"/v1/shelves/1/books/2",
+		"/v1/shelves/1:publish",
+		"/a/b/c",
+		"/a/x/b/y/b/c",
+		"/1/2",
+		"/api/v2/x/y",
+		"/docs",
+		"/docs/intro/setup",
+		"/export/7",
+		"/export/7/csv",
+		"/first",
+		"/items/42",
+		"/items/abc",
+		"/mixed/VALUE",
+		"/a%2",
+	}
+	optionSets := []match.MatchOptions{
+		{},
+		{CaseInsensitive: true},
+		{KeepFirstVariable: true},
+		{Unescape: match.UnescapeAllExceptReserved},
+		{Unescape: match.UnescapeAll},
+		{Unescape: match.UnescapeAll, DecodeSlashes: true},
+	}
+
+	var caps match.Captures
+	for _, s := range templates {
+		tmpl, err := parse.ParseTemplate(s)
+		require.NoError(t, err)
+
+		for _, opts := range optionSets {
+			prog, err := match.Compile(tmpl, &opts)
+			require.NoError(t, err)
+
+			for _, path := range paths {
+				expectedMatch, expectedVars, expectedErr := match.StrictMatch(tmpl, path, &opts)
+				matched, err := prog.MatchInto(path, &caps)
+				if expectedErr != nil {
+					require.ErrorIs(t, err, match.ErrMalformedPath, "%s %s %+v", s, path, opts)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, expectedMatch, matched, "%s %s %+v", s, path, opts)
+				if matched {
+					require.Equal(t, expectedVars, caps.Map(), "%s %s %+v", s, path, opts)
+				}
+			}
+		}
+	}
+}
+
+func TestProgramCaptures(t *testing.T) {
+	tmpl, err := parse.ParseTemplate("/users/{id}[/{tab}]")
+	require.NoError(t, err)
+	prog, err := match.Compile(tmpl, &match.MatchOptions{})
+	require.NoError(t, err)
+
+	var caps match.Captures
+	matched, err := prog.MatchInto("/users/alice/posts", &caps)
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, 2, caps.Len())
+	require.Equal(t, "id", caps.Name(0))
+	value, ok := caps.Value(0)
+	require.True(t, ok)
+	require.Equal(t, "alice", value)
+	value, ok = caps.Get("tab")
+	require.True(t, ok)
+	require.Equal(t, "posts", value)
+
+	// Values of a previous match are not kept
+	matched, err = prog.MatchInto("/users/bob", &caps)
+	require.NoError(t, err)
+	require.True(t, matched)
+	_, ok = caps.Get("tab")
+	require.False(t, ok)
+	_, ok = caps.Get("nosuchvar")
+	require.False(t, ok)
+}
+
+func TestProgramDoesNotAllocate(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		path        string
+	}{
+		{templateStr: "/users/{id}/posts/{post}", path: "/users/alice/posts/1"},
+		{templateStr: "/v1/{name=shelves/*/books/*}:publish", path: "/v1/shelves/1/books/2:publish"},
+		{templateStr: "/files/{path=**}/raw", path: "/files/a/b/c/raw"},
+		{templateStr: "/a/**/b/**/c", path: "/a/x/b/y/b/c"},
+		{templateStr: "/files/{name}.(json|yaml)", path: "/files/config.yaml"},
+		{templateStr: "/export/{id:int}[/{format?=json}]", path: "/export/7"},
+		{templateStr: "/users/{id}", path: "/nomatch/alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+			prog, err := match.Compile(tmpl, &match.MatchOptions{})
+			require.NoError(t, err)
+
+			var caps match.Captures
+			_, err = prog.MatchInto(tt.path, &caps) // grow the storage
+			require.NoError(t, err)
+
+			allocs := testing.AllocsPerRun(100, func() {
+				_, _ = prog.MatchInto(tt.path, &caps)
+			})
+			require.Zero(t, allocs)
+		})
+	}
+}
+
+func TestProgramBacktrackingIsBounded(t *testing.T) {
+	tmpl, err := parse.ParseTemplate("/**/a/**/a/**/a/**/a/**/a/**/a/**/a/**/b")
+	require.NoError(t, err)
+	prog, err := match.Compile(tmpl, &match.MatchOptions{})
+	require.NoError(t, err)
+
+	matched, err := prog.MatchInto("/"+strings.Repeat("a/", 200), &match.Captures{})
+	require.NoError(t, err)
+	require.False(t, matched)
+}
+
+func TestCompileError(t *testing.T) {
+	_, err := match.Compile(nil, &match.MatchOptions{})
+	require.Error(t, err)
+
+	// Templates built without the parser are validated too
+	tmpl := &pmpb.PathTemplate{
+		Segments: []*pmpb.Segment{
+			{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "id", Type: "nosuchtype"}}},
+		},
+	}
+	_, err = match.Compile(tmpl, &match.MatchOptions{})
+	require.Error(t, err)
+
+	tmpl = &pmpb.PathTemplate{
+		Segments: []*pmpb.Segment{
+			{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{
+				Name: "outer",
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "inner"}}},
+				},
+			}}},
+		},
+	}
+	_, err = match.Compile(tmpl, &match.MatchOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,347 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tsdkv/pathmatch/internal/rank"
+	"github.com/tsdkv/pathmatch/internal/utils"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrDuplicateID = errors.New("duplicate template id")
+
+// Router indexes a set of templates in a trie of path segments, so that a
+// path is matched against all of them in a single traversal.
+//
+// Literal segments are looked up in a map. Other single-segment parts
+// ('*', '{var}', composites and alternations) and '**' are fallback edges,
+// tried from the most to the least specific when the literal edge does not
+// lead to a match. Segments whose width is not known in advance, sub-pattern
+// variables and optional groups, end the trie: the rest of such a template
+// is matched with the backtracking matcher.
+type Router struct {
+	opts *MatchOptions
+	root *node
+	// verbs holds a separate trie for the templates of each custom verb.
+	verbs map[string]*node
+	ids   map[string]struct{}
+}
+
+// route is a template added to the router.
+type route struct {
+	id       string
+	template *pathmatchpb.PathTemplate
+	// seq is the order in which the template was added.
+	seq int
+}
+
+// better reports whether r wins over other when both match a path:
+// it is more specific, or equally specific and added first.
+func (r *route) better(other *route) bool {
+	if c := rank.Compare(r.template, other.template); c != 0 {
+		return c < 0
+	}
+	return r.seq < other.seq
+}
+
+// node is a node of the router trie.
+type node struct {
+	// literals are the edges of literal segments, keyed by their value.
+	literals map[string]*node
+	// edges are the fallback edges, ordered by rank.
+	edges []*edge
+	// routes are the templates ending at this node, in the order they were added.
+	routes []*route
+}
+
+// edge is a fallback edge of the trie. A tail edge has no child: its
+// segments are the rest of a template, matched as a whole.
+type edge struct {
+	seg   *pathmatchpb.Segment
+	child *node
+	tail  []*pathmatchpb.Segment
+	route *route
+}
+
+func NewRouter(opts *MatchOptions) *Router {
+	return &Router{
+		opts:  opts,
+		root:  &node{},
+		verbs: make(map[string]*node),
+		ids:   make(map[string]struct{}),
+	}
+}
+
+// Add adds a template to the router under the given id.
+func (r *Router) Add(id string, template *pathmatchpb.PathTemplate) error {
+	if template == nil {
+		return errors.New("template cannot be nil")
+	}
+	if _, exists := r.ids[id]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateID, id)
+	}
+	rt := &route{id: id, template: template, seq: len(r.ids)}
+	r.ids[id] = struct{}{}
+
+	n := r.root
+	if verb := template.GetVerb(); verb != "" {
+		key := r.key(verb)
+		if r.verbs[key] == nil {
+			r.verbs[key] = &node{}
+		}
+		n = r.verbs[key]
+	}
+
+	for i, seg := range template.Segments {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_Literal:
+			key := r.key(s.Literal.Value)
+			if n.literals == nil {
+				n.literals = make(map[string]*node)
+			}
+			if n.literals[key] == nil {
+				n.literals[key] = &node{}
+			}
+			n = n.literals[key]
+			continue
+
+		case *pathmatchpb.Segment_Variable:
+			if !HasSubPattern(s.Variable) {
+				n = n.child(seg)
+				continue
+			}
+
+		case *pathmatchpb.Segment_Star, *pathmatchpb.Segment_DoubleStar,
+			*pathmatchpb.Segment_Alternation, *pathmatchpb.Segment_Composite:
+			n = n.child(seg)
+			continue
+		}
+
+		// The rest of the template does not fit in the trie
+		n.insert(&edge{seg: seg, tail: template.Segments[i:], route: rt})
+		return nil
+	}
+	n.routes = append(n.routes, rt)
+	return nil
+}
+
+// child returns the node the fallback edge of seg leads to,
+// adding the edge if it does not exist yet.
+func (n *node) child(seg *pathmatchpb.Segment) *node {
+	for _, e := range n.edges {
+		if e.child != nil && proto.Equal(e.seg, seg) {
+			return e.child
+		}
+	}
+	e := &edge{seg: seg, child: &node{}}
+	n.insert(e)
+	return e.child
+}
+
+// insert adds a fallback edge after the edges of the same or lower rank.
+func (n *node) insert(e *edge) {
+	i := len(n.edges)
+	for i > 0 && rank.Segment(n.edges[i-1].seg) > rank.Segment(e.seg) {
+		i--
+	}
+	n.edges = slices.Insert(n.edges, i, e)
+}
+
+// key returns the key of a literal or verb in the trie maps.
+func (r *Router) key(s string) string {
+	if r.opts.CaseInsensitive {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// Match returns the id of the template matching the whole path, and the
+// variables it captured. When several templates match, the most specific
+// one wins, as ordered by rank.Compare. Among equally specific templates,
+// the one added first wins.
+func (r *Router) Match(path string) (id string, vars map[string]string, matched bool, err error) {
+	segments := utils.Split(path)
+
+	var best *route
+	if n := len(segments); n > 0 && len(r.verbs) > 0 {
+		last := segments[n-1]
+		if i := strings.LastIndexByte(last, ':'); i > 0 {
+			if root := r.verbs[r.key(last[i+1:])]; root != nil {
+				trimmed := append(segments[:n-1:n-1], last[:i])
+				if best, vars, err = r.match(root, trimmed); err != nil {
+					return "", nil, false, err
+				}
+			}
+		}
+	}
+
+	// The colon may also be part of the last segment of a template without a verb
+	rt, rtVars, err := r.match(r.root, segments)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if rt != nil && (best == nil || rt.better(best)) {
+		best, vars = rt, rtVars
+	}
+	if best == nil {
+		return "", nil, false, nil
+	}
+	return best.id, vars, true, nil
+}
+
+func (r *Router) match(root *node, segments []string) (*route, map[string]string, error) {
+	single, multi, err := decodeSegments(segments, r.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &routeWalk{matcher: matcher{path: single, multi: multi, opts: r.opts, varStart: -1}}
+	rt, err := w.walk(root, 0)
+	if rt == nil || err != nil {
+		return nil, nil, err
+	}
+	return rt, w.vars(), nil
+}
+
+// routeWalk holds the state of a single traversal of the router trie.
+type routeWalk struct {
+	matcher
+	// results records the outcome of walk for a node and a path index, so
+	// that the ends of '**' edges reaching the same node are walked once.
+	// It is created on the first '**' edge, as for the matcher.
+	results map[nodeState]walkResult
+}
+
+type nodeState struct {
+	n       *node
+	pathIdx int
+}
+
+// walkResult is the best route below a node and the captures it made.
+type walkResult struct {
+	rt   *route
+	caps []capture
+}
+
+// walk returns the best template matching path[pathIdx:] from node n,
+// leaving its captures in w.caps, or nil if none matches. The result
+// depends only on n and pathIdx, so it is remembered.
+//
+// The literal edge is tried first, then the fallback edges rank by rank.
+// The first rank with a match decides, as the segments before are the same
+// for every template below n. Edges of the same rank are all tried, and the
+// best of their matches wins.
+func (w *routeWalk) walk(n *node, pathIdx int) (*route, error) {
+	st := nodeState{n, pathIdx}
+	if res, ok := w.results[st]; ok {
+		w.caps = append(w.caps, res.caps...)
+		return res.rt, nil
+	}
+
+	mark := len(w.caps)
+	rt, err := w.walkNode(n, pathIdx)
+	if err == nil && w.results != nil {
+		w.results[st] = walkResult{rt, slices.Clone(w.caps[mark:])}
+	}
+	return rt, err
+}
+
+func (w *routeWalk) walkNode(n *node, pathIdx int) (*route, error) {
+	if pathIdx == len(w.path) && len(n.routes) > 0 {
+		return n.routes[0], nil
+	}
+
+	mark := len(w.caps)
+	if pathIdx < len(w.path) && n.literals != nil {
+		key := w.path[pathIdx]
+		if w.opts.CaseInsensitive {
+			key = strings.ToLower(key)
+		}
+		if child := n.literals[key]; child != nil {
+			if rt, err := w.walk(child, pathIdx+1); rt != nil || err != nil {
+				return rt, err
+			}
+			w.caps = w.caps[:mark]
+		}
+	}
+
+	for i := 0; i < len(n.edges); {
+		// edges[i:j] have the same rank
+		j := i + 1
+		for j < len(n.edges) && rank.Segment(n.edges[j].seg) == rank.Segment(n.edges[i].seg) {
+			j++
+		}
+
+		var best *route
+		var bestCaps []capture
+		for _, e := range n.edges[i:j] {
+			rt, err := w.walkEdge(e, pathIdx)
+			if err != nil {
+				return nil, err
+			}
+			if rt != nil && j-i == 1 {
+				return rt, nil
+			}
+			if rt != nil && (best == nil || rt.better(best)) {
+				best = rt
+				bestCaps = append(bestCaps[:0], w.caps[mark:]...)
+			}
+			w.caps = w.caps[:mark]
+		}
+		if best != nil {
+			w.caps = append(w.caps, bestCaps...)
+			return best, nil
+		}
+		i = j
+	}
+	return nil, nil
+}
+
+// walkEdge returns the best template matching path[pathIdx:] through e.
+func (w *routeWalk) walkEdge(e *edge, pathIdx int) (*route, error) {
+	if e.child == nil {
+		ok, err := w.matchSeq(e.tail, 0, pathIdx, func(end int) (bool, error) {
+			return end == len(w.path), nil
+		})
+		if !ok || err != nil {
+			return nil, err
+		}
+		return e.route, nil
+	}
+
+	if _, ok := e.seg.Segment.(*pathmatchpb.Segment_DoubleStar); !ok {
+		var rt *route
+		_, err := w.matchSegment(e.seg, pathIdx, func(next int) (bool, error) {
+			var err error
+			rt, err = w.walk(e.child, next)
+			return rt != nil, err
+		})
+		return rt, err
+	}
+
+	// The templates below e differ after the '**', so the first end
+	// reaching one of them may not reach the best one: every end is walked
+	if w.results == nil {
+		w.results = make(map[nodeState]walkResult)
+	}
+	mark := len(w.caps)
+	var best *route
+	var bestCaps []capture
+	_, err := w.matchSegment(e.seg, pathIdx, func(next int) (bool, error) {
+		rt, err := w.walk(e.child, next)
+		if rt != nil && (best == nil || rt.better(best)) {
+			best = rt
+			bestCaps = append(bestCaps[:0], w.caps[mark:]...)
+		}
+		return false, err
+	})
+	if best == nil || err != nil {
+		return nil, err
+	}
+	w.caps = append(w.caps[:mark], bestCaps...)
+	return best, nil
+}
@@ -0,0 +1,243 @@
+package match_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/parse"
+)
+
+func newRouter(t *testing.T, opts *match.MatchOptions, templates ...string) *match.Router {
+	t.Helper()
+	router := match.NewRouter(opts)
+	for _, s := range templates {
+		tmpl, err := parse.ParseTemplate(s)
+		require.NoError(t, err)
+		require.NoError(t, router.Add(s, tmpl))
+	}
+	return router
+}
+
+func TestRouter(t *testing.T) {
+	templates := []string{
+		"/users/{id}",
+		"/users/me",
+		"/users/{id}/posts/{post}",
+		"/users/*/posts/latest",
+		"/users/{id}/files/{path=**}",
+		"/users/{id}/**",
+		"/files/{name}.{ext}",
+		"/files/{name}",
+		"/api/(v1|v2)/status",
+		"/v1/{name=shelves/*}",
+		"/v1/{name=shelves/*}:archive",
+		"/docs[/{page}]",
+		"/export/{id}/{format?:json}",
+		"/**/health",
+		"/",
+	}
+
+	tests := []struct {
+		path         string
+		expectedID   string
+		expectedVars map[string]string
+	}{
+		{path: "/users/me", expectedID: "/users/me", expectedVars: map[string]string{}},
+		{path: "/users/alice", expectedID: "/users/{id}", expectedVars: map[string]string{"id": "alice"}},
+		{path: "/users/alice/posts/1", expectedID: "/users/{id}/posts/{post}", expectedVars: map[string]string{"id": "alice", "post": "1"}},
+		{path: "/users/alice/posts/latest", expectedID: "/users/*/posts/latest", expectedVars: map[string]string{}},
+		{path: "/users/alice/files/a/b", expectedID: "/users/{id}/files/{path=**}", expectedVars: map[string]string{"id": "alice", "path": "/a/b"}},
+		{path: "/users/alice/other/x", expectedID: "/users/{id}/**", expectedVars: map[string]string{"id": "alice"}},
+		{path: "/files/report.pdf", expectedID: "/files/{name}.{ext}", expectedVars: map[string]string{"name": "report", "ext": "pdf"}},
+		{path: "/files/README", expectedID: "/files/{name}", expectedVars: map[string]string{"name": "README"}},
+		{path: "/api/v2/status", expectedID: "/api/(v1|v2)/status", expectedVars: map[string]string{}},
+		{path: "/v1/shelves/1", expectedID: "/v1/{name=shelves/*}", expectedVars: map[string]string{"name": "/shelves/1"}},
+		{path: "/v1/shelves/1:archive", expectedID: "/v1/{name=shelves/*}:archive", expectedVars: map[string]string{"name": "/shelves/1"}},
+		{path: "/docs", expectedID: "/docs[/{page}]", expectedVars: map[string]string{}},
+		{path: "/docs/intro", expectedID: "/docs[/{page}]", expectedVars: map[string]string{"page": "intro"}},
+		{path: "/export/7", expectedID: "/export/{id}/{format?:json}", expectedVars: map[string]string{"id": "7", "format": "json"}},
+		{path: "/a/b/health", expectedID: "/**/health", expectedVars: map[string]string{}},
+		{path: "/health", expectedID: "/**/health", expectedVars: map[string]string{}},
+		{path: "/", expectedID: "/", expectedVars: map[string]string{}},
+		{path: "/api/v3/status"},
+		{path: "/v1/shelves/1:delete", expectedID: "/v1/{name=shelves/*}", expectedVars: map[string]string{"name": "/shelves/1:delete"}},
+		{path: "/v1/books/1:archive"},
+	}
+
+	router := newRouter(t, &match.MatchOptions{}, templates...)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			id, vars, matched, err := router.Match(tt.path)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedID != "", matched)
+			require.Equal(t, tt.expectedID, id)
+			if matched {
+				require.Equal(t, tt.expectedVars, vars)
+			}
+		})
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	router := newRouter(t, &match.MatchOptions{CaseInsensitive: true}, "/Users/{id}", "/v1/{name}:Publish")
+
+	id, vars, matched, err := router.Match("/USERS/Alice")
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, "/Users/{id}", id)
+	require.Equal(t, map[string]string{"id": "Alice"}, vars)
+
+	id, _, matched, err = router.Match("/V1/book:publish")
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, "/v1/{name}:Publish", id)
+
+	router = newRouter(t, &match.MatchOptions{Unescape: match.UnescapeAll}, "/files/{name}")
+	_, vars, matched, err = router.Match("/files/a%20b")
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, map[string]string{"name": "a b"}, vars)
+
+	_, _, _, err = router.Match("/files/a%2")
+	require.ErrorIs(t, err, match.ErrMalformedPath)
+}
+
+func TestRouterFirstAddedWins(t *testing.T) {
+	router := newRouter(t, &match.MatchOptions{}, "/items/{id}", "/items/{sku}")
+
+	id, vars, matched, err := router.Match("/items/42")
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, "/items/{id}", id)
+	require.Equal(t, map[string]string{"id": "42"}, vars)
+}
+
+func TestRouterMostSpecificWins(t *testing.T) {
+	tests := []struct {
+		templates    []string
+		path         string
+		expectedID   string
+		expectedVars map[string]string
+	}{
+		{
+			// The edge of "{a}" is shared by an earlier template, yet the
+			// first added of the equally specific templates wins
+			templates:    []string{"/x/{a}/y", "/x/{b}/{c}", "/x/{a}/{c}"},
+			path:         "/x/1/2",
+			expectedID:   "/x/{b}/{c}",
+			expectedVars: map[string]string{"b": "1", "c": "2"},
+		},
+		{
+			templates:    []string{"/x/{a}/{b}", "/x/*/y"},
+			path:         "/x/1/y",
+			expectedID:   "/x/*/y",
+			expectedVars: map[string]string{},
+		},
+		{
+			templates:    []string{"/v1/{name=**}", "/v1/{name=shelves/*}"},
+			path:         "/v1/shelves/1",
+			expectedID:   "/v1/{name=shelves/*}",
+			expectedVars: map[string]string{"name": "/shelves/1"},
+		},
+		{
+			templates:    []string{"/v1/**", "/v1/{name=*}/books"},
+			path:         "/v1/shelves/books",
+			expectedID:   "/v1/{name=*}/books",
+			expectedVars: map[string]string{"name": "shelves"},
+		},
+		{
+			templates:    []string{"/files/{name}.{ext}", "/files/{name}.json"},
+			path:         "/files/a.json",
+			expectedID:   "/files/{name}.json",
+			expectedVars: map[string]string{"name": "a"},
+		},
+		{
+			// The greedy end of '**' reaches "/**/{x}" first, but the other
+			// end of the edge reaches a more specific template
+			templates:    []string{"/**/{x}", "/**/a/**"},
+			path:         "/a/z",
+			expectedID:   "/**/a/**",
+			expectedVars: map[string]string{},
+		},
+		{
+			templates:    []string{"/{p=**}/{x}", "/{p=**}/a/{y}"},
+			path:         "/b/a/z",
+			expectedID:   "/{p=**}/a/{y}",
+			expectedVars: map[string]string{"p": "/b", "y": "z"},
+		},
+		{
+			// The verb trie is not preferred over a more specific template
+			templates:    []string{"/v1/{name}:archive", `/v1/x\:archive`},
+			path:         "/v1/x:archive",
+			expectedID:   `/v1/x\:archive`,
+			expectedVars: map[string]string{},
+		},
+		{
+			templates:    []string{"/v1/{name}", "/v1/{name}:archive"},
+			path:         "/v1/x:archive",
+			expectedID:   "/v1/{name}:archive",
+			expectedVars: map[string]string{"name": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			router := newRouter(t, &match.MatchOptions{}, tt.templates...)
+			id, vars, matched, err := router.Match(tt.path)
+			require.NoError(t, err)
+			require.True(t, matched)
+			require.Equal(t, tt.expectedID, id)
+			require.Equal(t, tt.expectedVars, vars)
+		})
+	}
+}
+
+func TestRouterDuplicateID(t *testing.T) {
+	tmpl, err := parse.ParseTemplate("/a")
+	require.NoError(t, err)
+
+	router := match.NewRouter(&match.MatchOptions{})
+	require.NoError(t, router.Add("a", tmpl))
+	require.ErrorIs(t, router.Add("a", tmpl), match.ErrDuplicateID)
+}
+
+func TestRouterManyTemplates(t *testing.T) {
+	// Each template is the only one matching its own path,
+	// even among thousands of templates sharing prefixes.
+	var templates []string
+	for i := range 1000 {
+		templates = append(templates, fmt.Sprintf("/svc%d/{id}/items/%d", i%10, i))
+	}
+	router := newRouter(t, &match.MatchOptions{}, templates...)
+
+	for i, s := range templates {
+		path := strings.Replace(s, "{id}", "x", 1)
+		id, vars, matched, err := router.Match(path)
+		require.NoError(t, err)
+		require.True(t, matched)
+		require.Equal(t, templates[i], id)
+		require.Equal(t, map[string]string{"id": "x"}, vars)
+	}
+}
+
+func TestRouterBacktrackingIsBounded(t *testing.T) {
+	router := newRouter(t, &match.MatchOptions{}, "/**/a/**/a/**/a/**/a/**/a/**/a/**/a/**/b")
+
+	_, _, matched, err := router.Match("/" + strings.Repeat("a/", 200))
+	require.NoError(t, err)
+	require.False(t, matched)
+}
+
+func TestRouterDoubleStarEndsAreBounded(t *testing.T) {
+	// Every end of each '**' is walked, as a later end may reach a better
+	// template, so a matching path must not walk the trie exponentially
+	router := newRouter(t, &match.MatchOptions{}, "/**/a/**/a/**/a/**/a/**/a/**/a/**/a/**", "/**/{x}")
+
+	id, _, matched, err := router.Match("/" + strings.Repeat("a/", 200))
+	require.NoError(t, err)
+	require.True(t, matched)
+	require.Equal(t, "/**/a/**/a/**/a/**/a/**/a/**/a/**/a/**", id)
+}
@@ -0,0 +1,113 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrMalformedPath = errors.New("malformed percent-encoding in path")
+
+// UnescapingMode controls how percent-encoded path segments are decoded
+// before they are matched and captured.
+type UnescapingMode int
+
+const (
+	// UnescapeNone matches and captures the path exactly as given.
+	// This is the default.
+	UnescapeNone UnescapingMode = iota
+	// UnescapeAllExceptReserved decodes every percent-encoded character
+	// except the reserved characters of RFC 6570, such as "%2F" and "%3A".
+	UnescapeAllExceptReserved
+	// UnescapeAll decodes every percent-encoded character. Values captured
+	// by multi-segment variables keep "%2F" encoded unless
+	// MatchOptions.DecodeSlashes is set, so that their '/' separators stay
+	// unambiguous.
+	UnescapeAll
+)
+
+// reserved are the reserved characters of RFC 6570.
+const reserved = ":/?#[]@!$&'()*+,;="
+
+// decodeSegments decodes the raw path segments according to the options.
+// It returns the segments used for matching and single-segment captures,
+// and the segments used to build multi-segment captures. When both are the
+// same, multi is nil.
+func decodeSegments(segments []string, opts *MatchOptions) (single, multi []string, err error) {
+	switch opts.Unescape {
+	case UnescapeNone:
+		return segments, nil, nil
+	case UnescapeAllExceptReserved:
+		single, err = unescapeAll(segments, isReserved)
+		return single, nil, err
+	case UnescapeAll:
+		if single, err = unescapeAll(segments, nil); err != nil || opts.DecodeSlashes {
+			return single, nil, err
+		}
+		multi, err = unescapeAll(segments, isSlash)
+		return single, multi, err
+	}
+	return nil, nil, fmt.Errorf("unknown unescaping mode %d", opts.Unescape)
+}
+
+func unescapeAll(segments []string, keep func(byte) bool) ([]string, error) {
+	decoded := make([]string, len(segments))
+	for i, s := range segments {
+		var err error
+		if decoded[i], err = unescape(s, keep); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+// unescape decodes the "%XX" sequences of s. Sequences decoding to a byte
+// for which keep returns true are left encoded as they were.
+func unescape(s string, keep func(byte) bool) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			return "", fmt.Errorf("%w: %q", ErrMalformedPath, s)
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if keep != nil && keep(c) {
+			b.WriteString(s[i : i+3])
+		} else {
+			b.WriteByte(c)
+		}
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func isReserved(c byte) bool {
+	return strings.IndexByte(reserved, c) >= 0
+}
+
+func isSlash(c byte) bool {
+	return c == '/'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -1,6 +1,35 @@
 package match
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// matchAlternation reports whether s equals one of the alternatives.
+func matchAlternation(alt *pathmatchpb.Alternation, s string, caseInsensitive bool) bool {
+	for _, v := range alt.Values {
+		if compareStrings(v, s, caseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimVerb removes the ":verb" suffix from the last segment of path.
+// It reports false if the path does not end with the verb or if the
+// verb is not attached to a segment (e.g. "/:verb").
+func trimVerb(path, verb string, caseInsensitive bool) (string, bool) {
+	path = strings.TrimRight(path, "/")
+	n := len(path) - len(verb) - 1
+	if n <= 0 || path[n] != ':' || path[n-1] == '/' {
+		return "", false
+	}
+	if !compareStrings(verb, path[n+1:], caseInsensitive) {
+		return "", false
+	}
+	return path[:n], true
+}
 
 func compareStrings(a, b string, caseInsensitive bool) bool {
 	if caseInsensitive {
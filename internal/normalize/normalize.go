@@ -0,0 +1,166 @@
+// Package normalize rewrites templates into a canonical form, so that
+// templates matching the same paths with the same captures compare equal.
+package normalize
+
+import (
+	"hash/fnv"
+	"slices"
+
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Normalize returns a normalized copy of the template. It rewrites:
+//
+//   - a variable whose sub-pattern is a single '*', "{x=*}", as the
+//     single-segment variable "{x}";
+//   - an alternation of one value, "(a)", as a literal, and the values of
+//     other alternations without duplicates. The values of an alternation
+//     that is a whole segment are sorted; those of an alternation in a
+//     composite segment keep their order, as the first value matching
+//     decides what the next parts capture;
+//   - the parts of a composite segment, merging adjacent literals and
+//     dropping empty ones, and a composite of a single part as that part;
+//   - consecutive '**' segments as one, and an optional group holding only
+//     another group as the inner group. Empty optional groups are dropped.
+func Normalize(template *pathmatchpb.PathTemplate) *pathmatchpb.PathTemplate {
+	return &pathmatchpb.PathTemplate{
+		Segments: segments(template.GetSegments()),
+		Verb:     template.GetVerb(),
+	}
+}
+
+// Equal reports whether two templates are equal once normalized.
+func Equal(a, b *pathmatchpb.PathTemplate) bool {
+	return proto.Equal(Normalize(a), Normalize(b))
+}
+
+// Fingerprint returns a hash of the normalized template. It is the FNV-1a
+// hash of the template's canonical text, so it is stable across processes.
+func Fingerprint(template *pathmatchpb.PathTemplate) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(parse.Format(Normalize(template))))
+	return h.Sum64()
+}
+
+func segments(segs []*pathmatchpb.Segment) []*pathmatchpb.Segment {
+	normalized := make([]*pathmatchpb.Segment, 0, len(segs))
+	for _, seg := range segs {
+		seg = segment(seg)
+		if seg == nil {
+			continue
+		}
+		if _, ok := seg.Segment.(*pathmatchpb.Segment_DoubleStar); ok && len(normalized) > 0 {
+			if _, prev := normalized[len(normalized)-1].Segment.(*pathmatchpb.Segment_DoubleStar); prev {
+				continue
+			}
+		}
+		normalized = append(normalized, seg)
+	}
+	return normalized
+}
+
+// segment returns a normalized copy of a segment, or nil if the segment
+// matches nothing but the empty path and can be dropped.
+func segment(seg *pathmatchpb.Segment) *pathmatchpb.Segment {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Variable:
+		return variable(s.Variable)
+
+	case *pathmatchpb.Segment_Alternation:
+		return alternation(s.Alternation.Values, true)
+
+	case *pathmatchpb.Segment_Composite:
+		parts := compositeParts(s.Composite.Parts)
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Composite{
+			Composite: &pathmatchpb.Composite{Parts: parts},
+		}}
+
+	case *pathmatchpb.Segment_Optional:
+		inner := segments(s.Optional.Segments)
+		if len(inner) == 0 {
+			return nil
+		}
+		if len(inner) == 1 && inner[0].GetOptional() != nil {
+			return inner[0]
+		}
+		return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Optional{
+			Optional: &pathmatchpb.Optional{Segments: inner},
+		}}
+	}
+	return proto.Clone(seg).(*pathmatchpb.Segment)
+}
+
+func variable(v *pathmatchpb.Variable) *pathmatchpb.Segment {
+	normalized := &pathmatchpb.Variable{
+		Name:         v.Name,
+		Segments:     segments(v.Segments),
+		Type:         v.Type,
+		Regex:        v.Regex,
+		DefaultValue: v.DefaultValue,
+	}
+	if len(normalized.Segments) == 1 {
+		if _, ok := normalized.Segments[0].Segment.(*pathmatchpb.Segment_Star); ok {
+			normalized.Segments = nil
+		}
+	}
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Variable{Variable: normalized}}
+}
+
+// alternation removes the duplicate values of an alternation, keeping the
+// first one, and sorts the values if sorted is set.
+func alternation(values []string, sorted bool) *pathmatchpb.Segment {
+	var unique []string
+	for _, v := range values {
+		if !slices.Contains(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	values = unique
+	if sorted {
+		slices.Sort(values)
+	}
+	if len(values) == 1 {
+		return literal(values[0])
+	}
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Alternation{
+		Alternation: &pathmatchpb.Alternation{Values: values},
+	}}
+}
+
+// compositeParts normalizes the parts of a composite segment, merging
+// adjacent literals.
+func compositeParts(parts []*pathmatchpb.Segment) []*pathmatchpb.Segment {
+	var normalized []*pathmatchpb.Segment
+	for _, part := range parts {
+		if alt := part.GetAlternation(); alt != nil {
+			part = alternation(alt.Values, false)
+		} else if part = segment(part); part == nil {
+			continue
+		}
+		lit, ok := part.Segment.(*pathmatchpb.Segment_Literal)
+		if !ok {
+			normalized = append(normalized, part)
+			continue
+		}
+		if lit.Literal.Value == "" {
+			continue
+		}
+		if n := len(normalized); n > 0 {
+			if prev, ok := normalized[n-1].Segment.(*pathmatchpb.Segment_Literal); ok {
+				prev.Literal.Value += lit.Literal.Value
+				continue
+			}
+		}
+		normalized = append(normalized, part)
+	}
+	return normalized
+}
+
+func literal(value string) *pathmatchpb.Segment {
+	return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Literal{Literal: &pathmatchpb.Literal{Value: value}}}
+}
@@ -0,0 +1,140 @@
+package normalize_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/normalize"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		templateStr string
+		expected    string
+	}{
+		{templateStr: "/users/{id}", expected: "/users/{id}"},
+		{templateStr: "/users/{id=*}", expected: "/users/{id}"},
+		{templateStr: "/users/{id=*:int}", expected: "/users/{id:int}"},
+		{templateStr: "/v1/{name=shelves/*}", expected: "/v1/{name=shelves/*}"},
+		{templateStr: "/api/(v2|v1|v2)", expected: "/api/(v1|v2)"},
+		{templateStr: "/api/(v1)/{kind=(users)}", expected: "/api/v1/{kind=users}"},
+		{templateStr: "/files/{name}.(json)", expected: "/files/{name}.json"},
+		{templateStr: "/files/{name}.(json|csv|json)", expected: "/files/{name}.(json|csv)"},
+		{templateStr: "/files/(ab|a){x}", expected: "/files/(ab|a){x}"},
+		{templateStr: "/a/**/**/b/{x=**/**}", expected: "/a/**/b/{x=**}"},
+		{templateStr: "/a[/[/b]]", expected: "/a[/b]"},
+		{templateStr: "/a[/[/b]/c]", expected: "/a[/[/b]/c]"},
+		{templateStr: "/a/{x?=1}:run", expected: "/a/{x?=1}:run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+			expected, err := parse.ParseTemplate(tt.expected)
+			require.NoError(t, err)
+
+			normalized := normalize.Normalize(tmpl)
+			require.Empty(t, cmp.Diff(expected, normalized, protocmp.Transform()))
+			require.Empty(t, cmp.Diff(normalized, normalize.Normalize(normalized), protocmp.Transform()), "normalization is idempotent")
+			require.True(t, normalize.Equal(tmpl, expected))
+			require.Equal(t, normalize.Fingerprint(expected), normalize.Fingerprint(tmpl))
+		})
+	}
+}
+
+func TestNormalizeComposite(t *testing.T) {
+	lit := func(v string) *pathmatchpb.Segment {
+		return &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Literal{Literal: &pathmatchpb.Literal{Value: v}}}
+	}
+	variable := &pathmatchpb.Segment{Segment: &pathmatchpb.Segment_Variable{Variable: &pathmatchpb.Variable{Name: "name"}}}
+	composite := func(parts ...*pathmatchpb.Segment) *pathmatchpb.PathTemplate {
+		return &pathmatchpb.PathTemplate{Segments: []*pathmatchpb.Segment{
+			{Segment: &pathmatchpb.Segment_Composite{Composite: &pathmatchpb.Composite{Parts: parts}}},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		template *pathmatchpb.PathTemplate
+		expected string
+	}{
+		{name: "adjacent literals", template: composite(variable, lit("."), lit(""), lit("json")), expected: "/{name}.json"},
+		{name: "single part", template: composite(variable), expected: "/{name}"},
+		{name: "literals only", template: composite(lit("a"), lit("b")), expected: "/ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := parse.ParseTemplate(tt.expected)
+			require.NoError(t, err)
+			require.Empty(t, cmp.Diff(expected, normalize.Normalize(tt.template), protocmp.Transform()))
+		})
+	}
+}
+
+func TestNotEqual(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{a: "/users/{id}", b: "/users/{uid}"},
+		{a: "/users/{id}", b: "/users/*"},
+		{a: "/users/{id}", b: "/users/{id:int}"},
+		{a: "/users/{id}", b: "/Users/{id}"},
+		{a: "/a/{x=**}", b: "/a/**"},
+		{a: "/files/(ab|a){x}", b: "/files/(a|ab){x}"},
+		{a: "/a/b", b: "/a/b:run"},
+		{a: "/a[/b]", b: "/a/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := parse.ParseTemplate(tt.a)
+			require.NoError(t, err)
+			b, err := parse.ParseTemplate(tt.b)
+			require.NoError(t, err)
+
+			require.False(t, normalize.Equal(a, b))
+			require.NotEqual(t, normalize.Fingerprint(a), normalize.Fingerprint(b))
+		})
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	tmpl, err := parse.ParseTemplate("/v1/{name=shelves/*}/books/{book}")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x2bb5843a9a25eb2a), normalize.Fingerprint(tmpl))
+}
+
+func TestNormalizeKeepsCaptures(t *testing.T) {
+	// Alternation values in a composite are tried in order, so reordering
+	// them would change what the next parts capture. "{x=*}" captures as
+	// "{x}" does, so rewriting it keeps the capture
+	tests := []struct {
+		templateStr string
+		path        string
+		expected    map[string]string
+	}{
+		{templateStr: "/(ab|a){x}", path: "/abc", expected: map[string]string{"x": "c"}},
+		{templateStr: "/(a|ab){x}", path: "/abc", expected: map[string]string{"x": "bc"}},
+		{templateStr: "/users/{id=*}", path: "/users/1", expected: map[string]string{"id": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateStr, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.templateStr)
+			require.NoError(t, err)
+
+			for _, tmpl := range []*pathmatchpb.PathTemplate{tmpl, normalize.Normalize(tmpl)} {
+				matched, vars, err := match.StrictMatch(tmpl, tt.path, &match.MatchOptions{})
+				require.NoError(t, err)
+				require.True(t, matched)
+				require.Equal(t, tt.expected, vars)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError is the error returned by ParseTemplate. It locates the failure
+// in the template and wraps one of the sentinel errors of the package.
+type ParseError struct {
+	// Template is the template text that failed to parse.
+	Template string
+	// Offset is the byte offset in Template of the offending token.
+	Offset int
+	// Token is the offending token.
+	Token Token
+	// Expected are the token types that would have been valid at Offset,
+	// if the failure is due to a missing token.
+	Expected []TokenType
+	// Err describes the failure and wraps a sentinel error, such as
+	// ErrUnexpectedToken, so that errors.Is reports the kind of failure.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v (at offset %d)", e.Err, e.Offset)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Caret renders the template on a first line and a '^' under the offending
+// position on a second line:
+//
+//	/users/{id
+//	          ^
+func (e *ParseError) Caret() string {
+	column := utf8.RuneCountInString(e.Template[:min(e.Offset, len(e.Template))])
+	return e.Template + "\n" + strings.Repeat(" ", column) + "^"
+}
+
+// segmentStart are the token types that can start a segment.
+var segmentStart = []TokenType{TokenLiteral, TokenStar, TokenDoubleStar, TokenLeftBrace, TokenLeftParen}
+
+// newError returns a ParseError for the token at the given offset of the
+// template. The template is set by ParseTemplate.
+func newError(offset int, tok Token, expected []TokenType, err error) error {
+	return &ParseError{Offset: offset, Token: tok, Expected: expected, Err: err}
+}
+
+// errorAt returns a ParseError for the current token of the lexer.
+func errorAt(lex *lexer, expected []TokenType, err error) error {
+	return newError(lex.Offset(), lex.Peek(), expected, err)
+}
+
+// withTemplate sets the template of a ParseError returned for it.
+func withTemplate(err error, template string) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Template = template
+	}
+	return err
+}
+
+// describe lists token types for an error message, e.g. "'=', ':' or '}'".
+func describe(types []TokenType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case TokenLiteral:
+			names[i] = "a literal"
+		case TokenEOF:
+			names[i] = "the end of the template"
+		default:
+			names[i] = "'" + tokenTypeNames[t] + "'"
+		}
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
@@ -0,0 +1,27 @@
+package parse
+
+import "strings"
+
+// EscapeLiteral escapes the reserved characters of a literal value with a
+// backslash, so that parsing the result yields the value unchanged.
+// Inside a variable definition '=' is reserved too. A ':' is always escaped,
+// since outside of braces it could otherwise start a custom verb.
+func EscapeLiteral(s string, inVariable bool) string {
+	reserved := reservedOutside + ":\\"
+	if inVariable {
+		reserved = escapable
+	}
+	if !strings.ContainsAny(s, reserved) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(reserved, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package parse
+
+import (
+	"strings"
+
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Format renders a template in the syntax read by ParseTemplate, so that
+// parsing the result yields a template equal to the one formatted.
+// Literals are escaped with EscapeLiteral, and an optional group holding
+// a single variable with a default value is rendered as "{name?=default}".
+func Format(tmpl *pmpb.PathTemplate) string {
+	var b strings.Builder
+	formatSegments(&b, tmpl.GetSegments())
+	if b.Len() == 0 {
+		b.WriteByte('/')
+	}
+	if verb := tmpl.GetVerb(); verb != "" {
+		b.WriteString(":" + verb)
+	}
+	return b.String()
+}
+
+// formatSegments writes slash-separated segments and optional groups.
+// A group starting the list follows a '/', as the parser expects one
+// before the first segment of a template or group.
+func formatSegments(b *strings.Builder, segs []*pmpb.Segment) {
+	for i, seg := range segs {
+		opt := seg.GetOptional()
+		if opt == nil {
+			b.WriteByte('/')
+			formatSegment(b, seg, false)
+			continue
+		}
+		if v := defaultVariable(opt); v != nil {
+			b.WriteByte('/')
+			formatOptionalVariable(b, v)
+			continue
+		}
+		if i == 0 {
+			b.WriteByte('/')
+		}
+		b.WriteByte('[')
+		formatSegments(b, opt.Segments)
+		b.WriteByte(']')
+	}
+}
+
+// formatSegment writes a single segment. inVariable is set for the segments
+// of a variable's sub-pattern, where more characters need escaping.
+func formatSegment(b *strings.Builder, seg *pmpb.Segment, inVariable bool) {
+	switch s := seg.Segment.(type) {
+	case *pmpb.Segment_Literal:
+		b.WriteString(EscapeLiteral(s.Literal.Value, inVariable))
+	case *pmpb.Segment_Star:
+		b.WriteByte('*')
+	case *pmpb.Segment_DoubleStar:
+		b.WriteString("**")
+	case *pmpb.Segment_Alternation:
+		b.WriteByte('(')
+		for i, v := range s.Alternation.Values {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			b.WriteString(EscapeLiteral(v, inVariable))
+		}
+		b.WriteByte(')')
+	case *pmpb.Segment_Composite:
+		for _, part := range s.Composite.Parts {
+			formatSegment(b, part, inVariable)
+		}
+	case *pmpb.Segment_Variable:
+		formatVariable(b, s.Variable)
+	case *pmpb.Segment_Optional:
+		// Not a part of a single segment: render the group in place
+		formatSegments(b, s.Optional.Segments)
+	}
+}
+
+func formatVariable(b *strings.Builder, v *pmpb.Variable) {
+	b.WriteByte('{')
+	b.WriteString(EscapeLiteral(v.Name, true))
+	if len(v.Segments) > 0 {
+		b.WriteByte('=')
+		for i, seg := range v.Segments {
+			if i > 0 {
+				b.WriteByte('/')
+			}
+			formatSegment(b, seg, true)
+		}
+	}
+	formatConstraint(b, v)
+	b.WriteByte('}')
+}
+
+func formatOptionalVariable(b *strings.Builder, v *pmpb.Variable) {
+	b.WriteByte('{')
+	b.WriteString(EscapeLiteral(v.Name, true))
+	b.WriteString("?=")
+	b.WriteString(EscapeLiteral(v.DefaultValue, true))
+	formatConstraint(b, v)
+	b.WriteByte('}')
+}
+
+// formatConstraint writes the ':type' or ':regex' suffix of a variable.
+// The regex is written as is, as the parser reads it as opaque text.
+func formatConstraint(b *strings.Builder, v *pmpb.Variable) {
+	switch {
+	case v.Type != "":
+		b.WriteString(":" + v.Type)
+	case v.Regex != "":
+		b.WriteString(":" + v.Regex)
+	}
+}
+
+// defaultVariable returns the variable of an optional group made of a
+// single variable with a default value, as parsed from "{name?=default}".
+func defaultVariable(opt *pmpb.Optional) *pmpb.Variable {
+	if len(opt.Segments) != 1 {
+		return nil
+	}
+	v := opt.Segments[0].GetVariable()
+	if v == nil || v.DefaultValue == "" || len(v.Segments) > 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+
+	"github.com/tsdkv/pathmatch/internal/parse"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "/", expected: "/"},
+		{input: "/users/{id}", expected: "/users/{id}"},
+		{input: "/users//{id}/", expected: "/users/{id}"},
+		{input: "/files/*/**", expected: "/files/*/**"},
+		{input: "/v1/{name=shelves/*}/books/{book=**}", expected: "/v1/{name=shelves/*}/books/{book=**}"},
+		{input: "/items/{id:int}/{slug:[a-z]+}", expected: "/items/{id:int}/{slug:[a-z]+}"},
+		{input: "/d/{d=**:[0-9]{4}\\}x}", expected: "/d/{d=**:[0-9]{4}\\}x}"},
+		{input: "/files/{name}.{ext}", expected: "/files/{name}.{ext}"},
+		{input: "/src/**/test/*.go", expected: "/src/**/test/*.go"},
+		{input: "/files/\\*.txt", expected: "/files/\\*.txt"},
+		{input: "/q/a=b/c", expected: "/q/a=b/c"},
+		{input: "/a/b\\:c", expected: "/a/b\\:c"},
+		{input: "/a:b/c", expected: "/a\\:b/c"},
+		{input: "/{v=a\\=b}", expected: "/{v=a\\=b}"},
+		{input: "/api/(v1|v2)/{kind=(users|groups)}", expected: "/api/(v1|v2)/{kind=(users|groups)}"},
+		{input: "/reports/{year}[/{month}[/{day}]]", expected: "/reports/{year}[/{month}[/{day}]]"},
+		{input: "/users/{id}[/profile/*]/x", expected: "/users/{id}[/profile/*]/x"},
+		{input: "/export/{format?:json}", expected: "/export/{format?=json}"},
+		{input: "/p/{page?=1:int}", expected: "/p/{page?=1:int}"},
+		{input: "/p/{page?}", expected: "/p[/{page}]"},
+		{input: "/p/{page?=:int}", expected: "/p[/{page:int}]"},
+		{input: "/[/a]/b", expected: "/[/a]/b"},
+		{input: "/{x?}", expected: "/[/{x}]"},
+		{input: "/a[/[/b]/c]", expected: "/a[/[/b]/c]"},
+		{input: "/v1/{name=books/*}:publish", expected: "/v1/{name=books/*}:publish"},
+		{input: "/a[/b]:v", expected: "/a[/b]:v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate(tt.input)
+			require.NoError(t, err)
+
+			formatted := parse.Format(tmpl)
+			require.Equal(t, tt.expected, formatted)
+
+			reparsed, err := parse.ParseTemplate(formatted)
+			require.NoError(t, err)
+			require.Empty(t, cmp.Diff(tmpl, reparsed, protocmp.Transform()))
+		})
+	}
+}
+
+func TestFormatLiterals(t *testing.T) {
+	// Literals built without the parser are escaped as needed
+	values := []string{"plain", "a=b", "*.txt", "{id}", `a\b`, "x:y", "[opt]", "(a|b)", "**", "a?b"}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			lit := &pmpb.Segment{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: value}}}
+			tmpl := &pmpb.PathTemplate{Segments: []*pmpb.Segment{
+				lit,
+				{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "v", Segments: []*pmpb.Segment{lit}}}},
+				{Segment: &pmpb.Segment_Alternation{Alternation: &pmpb.Alternation{Values: []string{value, "b"}}}},
+				lit,
+			}}
+
+			reparsed, err := parse.ParseTemplate(parse.Format(tmpl))
+			require.NoError(t, err)
+			require.Empty(t, cmp.Diff(tmpl, reparsed, protocmp.Transform()))
+		})
+	}
+}
+
+func FuzzFormat(f *testing.F) {
+	seeds := []string{
+		"/users/{id}",
+		"/v1/{name=shelves/*}/books/{book=**}:publish",
+		"/files/{name}.{ext}",
+		"/src/**/test/*.go",
+		"/items/{id:int}/{slug:[a-z]{2}}",
+		"/api/(v1|v2)/{kind=(users|groups)}",
+		"/reports/{year}[/{month?=01}[/{day}]]",
+		"/export/{format?:json}",
+		`/files/\*.txt/a\:b/{v=x\=y}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		tmpl, err := parse.ParseTemplate(input)
+		if err != nil {
+			t.Skip()
+		}
+
+		formatted := parse.Format(tmpl)
+		reparsed, err := parse.ParseTemplate(formatted)
+		require.NoError(t, err, "formatted %q as %q", input, formatted)
+		require.Empty(t, cmp.Diff(tmpl, reparsed, protocmp.Transform()), "formatted %q as %q", input, formatted)
+
+		// Formatting is canonical
+		require.Equal(t, formatted, parse.Format(reparsed))
+	})
+}
@@ -2,16 +2,28 @@ package parse
 
 import "strings"
 
+const (
+	// reservedOutside are the characters ending a literal outside of braces.
+	reservedOutside = "/*{}[]()|"
+	// reservedInside are the characters ending a literal inside of braces.
+	reservedInside = reservedOutside + "=:?"
+	// escapable are the characters that can follow a backslash in a literal.
+	escapable = reservedInside + "\\"
+)
+
 type lexer struct {
-	input          string
-	curr           Token
-	prev           Token
-	pos            int
-	meetDoubleStar bool // Indicates if the lexer has encountered a '**' token
+	input    string
+	curr     Token
+	prev     Token
+	currPos  int // Byte offset of the current token in the input
+	prevPos  int // Byte offset of the previous token in the input
+	pos      int
+	inBraces bool // Indicates if the lexer is inside a variable definition
+	rawNext  bool // Indicates if the next token is an opaque variable constraint
 }
 
 func NewLexer(s string) *lexer {
-	lex := &lexer{input: s, pos: 0, meetDoubleStar: false}
+	lex := &lexer{input: s, pos: 0}
 	if len(lex.input) == 0 {
 		lex.curr = Token{Type: TokenEOF}
 	} else {
@@ -37,6 +49,16 @@ func (l *lexer) Prev() Token {
 	return l.prev
 }
 
+// Returns the byte offset of the current token in the input
+func (l *lexer) Offset() int {
+	return l.currPos
+}
+
+// Returns the byte offset of the previous token in the input
+func (l *lexer) PrevOffset() int {
+	return l.prevPos
+}
+
 func (l *lexer) Rest() string {
 	if l.pos >= len(l.input) {
 		return ""
@@ -50,23 +72,22 @@ func (l *lexer) Match(tok TokenType) bool {
 	if l.curr.Type != tok {
 		return false
 	}
-	if tok == TokenDoubleStar {
-		l.meetDoubleStar = true // Mark that we have encountered a '**' token
-	}
-	l.prev = l.curr
+	l.prev, l.prevPos = l.curr, l.currPos
+	l.currPos = l.pos
 	l.curr = l.nextToken()
 	return true
 }
 
-func (l *lexer) MeetDoubleStar() bool {
-	return l.meetDoubleStar
-}
-
 func (l *lexer) nextToken() Token {
 	if l.pos >= len(l.input) {
 		return Token{Type: TokenEOF}
 	}
 
+	if l.rawNext {
+		l.rawNext = false
+		return l.rawToken()
+	}
+
 	ch := l.input[l.pos]
 	switch ch {
 	case '/':
@@ -82,23 +103,111 @@ func (l *lexer) nextToken() Token {
 		return Token{Type: TokenStar}
 	case '{':
 		l.advance()
+		l.inBraces = true
 		return Token{Type: TokenLeftBrace}
 	case '}':
 		l.advance()
+		l.inBraces = false
 		return Token{Type: TokenRightBrace}
 	case '=':
+		// '=' is only reserved inside a variable definition
+		if !l.inBraces {
+			return l.literalToken()
+		}
 		l.advance()
 		return Token{Type: TokenEq}
+	case '[':
+		l.advance()
+		return Token{Type: TokenLeftBracket}
+	case ']':
+		l.advance()
+		return Token{Type: TokenRightBracket}
+	case '(':
+		l.advance()
+		return Token{Type: TokenLeftParen}
+	case ')':
+		l.advance()
+		return Token{Type: TokenRightParen}
+	case '|':
+		l.advance()
+		return Token{Type: TokenPipe}
+	case '?':
+		// '?' is only reserved inside a variable definition
+		if !l.inBraces {
+			return l.literalToken()
+		}
+		l.advance()
+		return Token{Type: TokenQuestion}
+	case ':':
+		// ':' is only reserved inside a variable definition
+		if !l.inBraces {
+			return l.literalToken()
+		}
+		l.advance()
+		l.rawNext = true
+		return Token{Type: TokenColon}
 	default:
-		start := l.pos
-		end := strings.IndexAny(l.input[l.pos:], "/*{}=")
-		if end == -1 {
-			end = len(l.input)
-		} else {
-			end += l.pos
+		return l.literalToken()
+	}
+}
+
+// literalToken reads a literal up to the next reserved character.
+// A backslash escapes a reserved character (or another backslash), so
+// `\*.txt` is the literal "*.txt". Any other escape yields a TokenUnknown
+// holding the offending sequence.
+func (l *lexer) literalToken() Token {
+	reserved := reservedOutside
+	if l.inBraces {
+		reserved = reservedInside
+	}
+
+	var b strings.Builder
+	start := l.pos
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		if ch == '\\' {
+			if l.pos+1 >= len(l.input) || strings.IndexByte(escapable, l.input[l.pos+1]) < 0 {
+				end := min(l.pos+2, len(l.input))
+				bad := l.input[l.pos:end]
+				// The token is the escape sequence, not the whole literal
+				l.currPos = l.pos
+				l.pos = end
+				return Token{Type: TokenUnknown, Value: bad}
+			}
+			b.WriteString(l.input[start:l.pos])
+			start = l.pos + 1 // keep the escaped character
+			l.pos += 2
+			continue
 		}
-		value := l.input[start:end]
-		l.pos = end
-		return Token{Type: TokenLiteral, Value: value}
+		if strings.IndexByte(reserved, ch) >= 0 {
+			break
+		}
+		l.advance()
+	}
+	b.WriteString(l.input[start:l.pos])
+	return Token{Type: TokenLiteral, Value: b.String()}
+}
+
+// rawToken reads a variable constraint (a type name or a regular expression)
+// as opaque text up to the '}' closing the variable. Nested braces are
+// balanced and a backslash escapes the next character, so expressions like
+// "[0-9]{2}" or `a\}b` are kept intact.
+func (l *lexer) rawToken() Token {
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '\\':
+			l.advance()
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return Token{Type: TokenLiteral, Value: l.input[start:l.pos]}
+			}
+			depth--
+		}
+		l.advance()
 	}
+	return Token{Type: TokenLiteral, Value: l.input[start:l.pos]}
 }
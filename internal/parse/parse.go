@@ -3,44 +3,148 @@ package parse
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
+	"github.com/tsdkv/pathmatch/internal/vartype"
 	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
 )
 
 var (
 	ErrUnexpectedEndOfInput = errors.New("unexpected end of input")
-	ErrUnexpectedDoubleStar = errors.New("unexpected '**' token in the middle of the path")
+	ErrUnexpectedDoubleStar = errors.New("unexpected '**' token, it must be a whole segment")
 	ErrUnexpectedToken      = errors.New("unexpected token")
 	ErrSubVariable          = errors.New("sub variables are not allowed in thix context")
+	ErrUnknownType          = vartype.ErrUnknownType
+	ErrInvalidRegex         = errors.New("invalid variable regex")
+	ErrEmptyConstraint      = errors.New("empty variable constraint")
+	ErrInvalidComposite     = errors.New("invalid composite segment")
+	ErrAmbiguousVariables   = errors.New("ambiguous adjacent variables")
+	ErrInvalidVerb          = errors.New("invalid verb")
+	ErrEmptyAlternative     = errors.New("empty alternative")
+	ErrInvalidEscape        = errors.New("invalid escape sequence")
+	ErrInvalidDefault       = errors.New("default value does not satisfy the variable constraint")
 )
 
 // ParseTemplate parses a path template string and returns a PathMatch object
-// or an error if the template is invalid.
+// or an error if the template is invalid. The error is a *ParseError.
 func ParseTemplate(s string) (*pmpb.PathTemplate, error) {
-	lex := NewLexer(s)
-	if lex == nil {
-		return nil, fmt.Errorf("failed to create lexer for input: %s", s)
+	tmpl, err := parseTemplate(s)
+	if err != nil {
+		return nil, withTemplate(err, s)
 	}
+	return tmpl, nil
+}
+
+func parseTemplate(s string) (*pmpb.PathTemplate, error) {
+	s, verb, err := splitVerb(s)
+	if err != nil {
+		return nil, err
+	}
+
+	lex := NewLexer(s)
 
 	if !lex.Match(TokenSlash) {
-		return nil, fmt.Errorf("expected leading '/', got: %s", lex.Peek())
+		err := fmt.Errorf("%w: expected leading '/', got: %s", ErrUnexpectedToken, lex.Peek())
+		return nil, errorAt(lex, []TokenType{TokenSlash}, err)
 	}
 
-	return parseSegments(lex)
+	tmpl, err := parseSegments(lex)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Verb = verb
+	return tmpl, nil
+}
+
+// splitVerb splits a trailing custom verb (e.g. ":publish") off the template.
+// Only an unescaped ':' in the last segment and outside of a variable
+// definition starts a verb, so "/a:b/c" and `/a/b\:c` have no verb while
+// "/a/b:c" has the verb "c".
+func splitVerb(s string) (string, string, error) {
+	depth := 0
+	colon := -1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // escaped character
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				colon = -1
+			}
+		case ':':
+			if depth == 0 {
+				colon = i
+			}
+		}
+	}
+	if colon == -1 {
+		return s, "", nil
+	}
+
+	verb := s[colon+1:]
+	if verb == "" {
+		err := fmt.Errorf("%w: verb after ':' cannot be empty", ErrUnexpectedEndOfInput)
+		return "", "", newError(len(s), Token{Type: TokenEOF}, []TokenType{TokenLiteral}, err)
+	}
+	if strings.ContainsAny(verb, "*{}[]()|\\") {
+		err := fmt.Errorf("%w: %q", ErrInvalidVerb, verb)
+		return "", "", newError(colon+1, Token{Type: TokenLiteral, Value: verb}, nil, err)
+	}
+	if colon == 0 || s[colon-1] == '/' {
+		err := fmt.Errorf("%w: verb %q must follow a segment", ErrInvalidVerb, verb)
+		return "", "", newError(colon, Token{Type: TokenColon}, nil, err)
+	}
+	return s[:colon], verb, nil
 }
 
 func parseSegments(lex *lexer) (*pmpb.PathTemplate, error) {
+	segments, err := parseSegmentList(lex, TokenEOF)
+	if err != nil {
+		return nil, err
+	}
+	return &pmpb.PathTemplate{Segments: segments}, nil
+}
+
+// parseSegmentList parses slash-separated segments and optional groups
+// until the end token, which is consumed.
+func parseSegmentList(lex *lexer, end TokenType) ([]*pmpb.Segment, error) {
 	segments := make([]*pmpb.Segment, 0)
 
 	for {
-		if lex.Match(TokenEOF) {
+		if lex.Match(end) {
 			break
 		}
 
+		if lex.Match(TokenEOF) {
+			err := fmt.Errorf("%w: optional group must be closed with ']'", ErrUnexpectedEndOfInput)
+			return nil, errorAt(lex, []TokenType{TokenRightBracket}, err)
+		}
+
 		if lex.Match(TokenSlash) {
 			continue
 		}
 
+		if lex.Match(TokenLeftBracket) {
+			segment, err := parseOptional(lex)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			continue
+		}
+
+		if lex.Peek().Type == TokenRightBracket {
+			err := fmt.Errorf("%w: ']' without matching '['", ErrUnexpectedToken)
+			return nil, errorAt(lex, []TokenType{TokenSlash, TokenLeftBracket, TokenEOF}, err)
+		}
+
 		segment, err := parseSegment(lex, true)
 		if err != nil {
 			return nil, err
@@ -48,24 +152,128 @@ func parseSegments(lex *lexer) (*pmpb.PathTemplate, error) {
 		segments = append(segments, segment)
 	}
 
-	return &pmpb.PathTemplate{Segments: segments}, nil
+	return segments, nil
+}
+
+// parseOptional parses an optional group after its opening '['.
+// The group must start with '/' and contain at least one segment.
+func parseOptional(lex *lexer) (*pmpb.Segment, error) {
+	if !lex.Match(TokenSlash) {
+		err := fmt.Errorf("%w: optional group must start with '/', got: %s", ErrUnexpectedToken, lex.Peek())
+		return nil, errorAt(lex, []TokenType{TokenSlash}, err)
+	}
+
+	segments, err := parseSegmentList(lex, TokenRightBracket)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		// The closing ']' was consumed
+		err := fmt.Errorf("%w: optional group must have at least one segment", ErrUnexpectedEndOfInput)
+		return nil, newError(lex.PrevOffset(), lex.Prev(), segmentStart, err)
+	}
+
+	switch lex.Peek().Type {
+	case TokenSlash, TokenLeftBracket, TokenRightBracket, TokenEOF:
+	default:
+		err := fmt.Errorf("%w: expected '/' after optional group, got: %s", ErrUnexpectedToken, lex.Peek())
+		return nil, errorAt(lex, []TokenType{TokenSlash, TokenLeftBracket, TokenRightBracket, TokenEOF}, err)
+	}
+
+	return &pmpb.Segment{
+		Segment: &pmpb.Segment_Optional{
+			Optional: &pmpb.Optional{Segments: segments},
+		},
+	}, nil
 }
 
 // parseSegment parses a single segment of the path template.
-// It can be a literal, a wildcard ('*'), a double wildcard ('**'), or a variable.
+// It can be a literal, a wildcard ('*'), a double wildcard ('**'), a variable,
+// or a composite of several literal and variable parts (e.g. "{name}.{ext}").
 // If expectVar is true, it expects a variable segment and will parse it accordingly.
 // If expectVar is false, it will not parse a variable and will return an error if it encounters one.
 func parseSegment(lex *lexer, expectVar bool) (*pmpb.Segment, error) {
-	if !lex.MeetDoubleStar() && lex.Match(TokenDoubleStar) {
-		return &pmpb.Segment{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}}, nil
+	offsets, tokens := []int{lex.Offset()}, []Token{lex.Peek()}
+	first, err := parseSegmentPart(lex, expectVar)
+	if err != nil || atSegmentEnd(lex) {
+		return first, err
 	}
-	// If we encounter '**' in the middle of the path, it's an error
-	if lex.MeetDoubleStar() {
-		return nil, ErrUnexpectedDoubleStar
+
+	parts := []*pmpb.Segment{first}
+	for !atSegmentEnd(lex) {
+		offsets, tokens = append(offsets, lex.Offset()), append(tokens, lex.Peek())
+		part, err := parseSegmentPart(lex, expectVar)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	if i, err := validateCompositeParts(parts); err != nil {
+		return nil, newError(offsets[i], tokens[i], nil, err)
+	}
+	return &pmpb.Segment{
+		Segment: &pmpb.Segment_Composite{
+			Composite: &pmpb.Composite{Parts: parts},
+		},
+	}, nil
+}
+
+// atSegmentEnd reports whether the current token ends a segment.
+func atSegmentEnd(lex *lexer) bool {
+	switch lex.Peek().Type {
+	case TokenSlash, TokenEOF, TokenRightBrace, TokenColon, TokenLeftBracket, TokenRightBracket:
+		return true
+	}
+	return false
+}
+
+// validateCompositeParts checks that a composite segment consists of literals,
+// alternations, wildcards ('*') and simple variables, with no two variables
+// or wildcards next to each other. It returns the index of the invalid part.
+func validateCompositeParts(parts []*pmpb.Segment) (int, error) {
+	prevVar := ""
+	for i, part := range parts {
+		switch p := part.Segment.(type) {
+		case *pmpb.Segment_Literal, *pmpb.Segment_Alternation:
+			prevVar = ""
+		case *pmpb.Segment_Star:
+			if prevVar != "" {
+				return i, fmt.Errorf("%w: '%s' and '*'", ErrAmbiguousVariables, prevVar)
+			}
+			prevVar = "*"
+		case *pmpb.Segment_Variable:
+			if len(p.Variable.Segments) > 0 {
+				return i, fmt.Errorf("%w: variable '%s' cannot have a sub-pattern", ErrInvalidComposite, p.Variable.Name)
+			}
+			if prevVar != "" {
+				return i, fmt.Errorf("%w: '%s' and '%s'", ErrAmbiguousVariables, prevVar, p.Variable.Name)
+			}
+			prevVar = p.Variable.Name
+		case *pmpb.Segment_DoubleStar:
+			return i, ErrUnexpectedDoubleStar
+		case *pmpb.Segment_Optional:
+			return i, fmt.Errorf("%w: optional variable '%s' must be a whole segment", ErrInvalidComposite, variableOf(part).GetName())
+		default:
+			return i, fmt.Errorf("%w: unexpected part %T", ErrInvalidComposite, p)
+		}
+	}
+	return 0, nil
+}
+
+// parseSegmentPart parses a literal, a wildcard, a double wildcard or a variable.
+func parseSegmentPart(lex *lexer, expectVar bool) (*pmpb.Segment, error) {
+	if err := checkEscape(lex); err != nil {
+		return nil, err
+	}
+	if lex.Match(TokenDoubleStar) {
+		return &pmpb.Segment{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}}, nil
 	}
 	if lex.Match(TokenStar) {
 		return &pmpb.Segment{Segment: &pmpb.Segment_Star{}}, nil
 	}
+	if lex.Match(TokenLeftParen) {
+		return parseAlternation(lex)
+	}
 	if lex.Match(TokenLiteral) {
 		return &pmpb.Segment{
 			Segment: &pmpb.Segment_Literal{
@@ -80,66 +288,254 @@ func parseSegment(lex *lexer, expectVar bool) (*pmpb.Segment, error) {
 		return parseVariable(lex)
 	}
 	// sub variables are not allowed
+	offset, tok := lex.Offset(), lex.Peek()
 	seg, err := parseVariable(lex)
 	if err == nil {
-		return nil, fmt.Errorf("%w: got %q", ErrSubVariable, seg.Segment.(*pmpb.Segment_Variable).Variable.Name)
+		return nil, newError(offset, tok, nil, fmt.Errorf("%w: got %q", ErrSubVariable, variableOf(seg).GetName()))
 	}
 	return nil, err
 }
 
+// checkEscape reports an invalid escape sequence at the current token.
+func checkEscape(lex *lexer) error {
+	if lex.Peek().Type == TokenUnknown {
+		return errorAt(lex, nil, fmt.Errorf("%w: %q", ErrInvalidEscape, lex.Peek().Value))
+	}
+	return nil
+}
+
+// parseAlternation parses a group of literal alternatives after its opening '('.
+func parseAlternation(lex *lexer) (*pmpb.Segment, error) {
+	var values []string
+	for {
+		if err := checkEscape(lex); err != nil {
+			return nil, err
+		}
+		if !lex.Match(TokenLiteral) {
+			if lex.Peek().Type == TokenEOF {
+				err := fmt.Errorf("%w: alternation must be closed with ')'", ErrUnexpectedEndOfInput)
+				return nil, errorAt(lex, []TokenType{TokenLiteral}, err)
+			}
+			err := fmt.Errorf("%w: expected literal in alternation, got: %s", ErrEmptyAlternative, lex.Peek())
+			return nil, errorAt(lex, []TokenType{TokenLiteral}, err)
+		}
+		values = append(values, lex.Prev().Value)
+
+		if lex.Match(TokenRightParen) {
+			break
+		}
+		if !lex.Match(TokenPipe) {
+			expected := []TokenType{TokenPipe, TokenRightParen}
+			if lex.Peek().Type == TokenEOF {
+				err := fmt.Errorf("%w: alternation must be closed with ')'", ErrUnexpectedEndOfInput)
+				return nil, errorAt(lex, expected, err)
+			}
+			err := fmt.Errorf("%w: expected '|' or ')' in alternation, got: %s", ErrUnexpectedToken, lex.Peek())
+			return nil, errorAt(lex, expected, err)
+		}
+	}
+
+	return &pmpb.Segment{
+		Segment: &pmpb.Segment_Alternation{
+			Alternation: &pmpb.Alternation{Values: values},
+		},
+	}, nil
+}
+
 func parseVariable(lex *lexer) (*pmpb.Segment, error) {
 	if !lex.Match(TokenLeftBrace) {
-		return nil, fmt.Errorf("unexpected token: %s", lex.Peek())
+		return nil, errorAt(lex, segmentStart, fmt.Errorf("%w: %s", ErrUnexpectedToken, lex.Peek()))
+	}
+	if err := checkEscape(lex); err != nil {
+		return nil, err
 	}
 	if !lex.Match(TokenLiteral) {
-		return nil, fmt.Errorf("expected variable name after '{', got: %s", lex.Peek())
+		err := fmt.Errorf("%w: expected variable name after '{', got: %s", ErrUnexpectedToken, lex.Peek())
+		return nil, errorAt(lex, []TokenType{TokenLiteral}, err)
 	}
 	varName := lex.Prev().Value
 
-	if lex.Match(TokenRightBrace) {
-		return &pmpb.Segment{
-			Segment: &pmpb.Segment_Variable{
-				Variable: &pmpb.Variable{
-					Name:     varName,
-					Segments: nil, // Segments will be filled if there is an '='
-				},
-			},
-		}, nil
-	}
-	if lex.Match(TokenEOF) {
-		return nil, fmt.Errorf("%w: variable '%s' must be closed with '}'", ErrUnexpectedEndOfInput, varName)
-	}
-
-	if !lex.Match(TokenEq) {
-		return nil, fmt.Errorf("expected '=' or '/' after variable name '%s', got: %s", varName, lex.Peek())
+	if lex.Match(TokenQuestion) {
+		return parseOptionalVariable(lex, varName)
 	}
 
 	var segments []*pmpb.Segment
-	for !lex.Match(TokenRightBrace) {
-		if lex.Match(TokenEOF) {
-			return nil, fmt.Errorf("%w: variable '%s'", ErrUnexpectedEndOfInput, varName)
-		}
+	if lex.Match(TokenEq) {
+		for lex.Peek().Type != TokenRightBrace && lex.Peek().Type != TokenColon {
+			if lex.Peek().Type == TokenEOF {
+				expected := []TokenType{TokenSlash, TokenColon, TokenRightBrace}
+				if len(segments) == 0 {
+					expected = segmentStart
+				}
+				err := fmt.Errorf("%w: variable '%s'", ErrUnexpectedEndOfInput, varName)
+				return nil, errorAt(lex, expected, err)
+			}
 
-		if lex.Match(TokenSlash) {
-			continue
+			if lex.Match(TokenSlash) {
+				continue
+			}
+
+			segment, err := parseSegment(lex, false)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
 		}
 
-		segment, err := parseSegment(lex, false)
-		if err != nil {
-			return nil, err
+		if len(segments) == 0 {
+			err := fmt.Errorf("%w: variable '%s' must have at least one segment after '=', got: %s", ErrUnexpectedToken, varName, lex.Peek())
+			return nil, errorAt(lex, segmentStart, err)
 		}
-		segments = append(segments, segment)
 	}
 
-	if len(segments) == 0 {
-		return nil, fmt.Errorf("%w: variable '%s' must have at least one segment after '='", ErrUnexpectedEndOfInput, varName)
+	varType, varRegex, err := parseVariableConstraint(lex, varName)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := []TokenType{TokenRightBrace}
+	if segments == nil && varType == "" && varRegex == "" {
+		expected = []TokenType{TokenEq, TokenColon, TokenQuestion, TokenRightBrace}
+	}
+	if err := closeVariable(lex, varName, expected); err != nil {
+		return nil, err
 	}
 	return &pmpb.Segment{
 		Segment: &pmpb.Segment_Variable{
 			Variable: &pmpb.Variable{
 				Name:     varName,
 				Segments: segments,
+				Type:     varType,
+				Regex:    varRegex,
+			},
+		},
+	}, nil
+}
+
+// parseOptionalVariable parses the rest of an optional variable after its '?'.
+// The default value follows '=' and may be followed by a constraint, as in
+// "{id?=0:int}". The shorthand "{format?:json}" takes everything after ':'
+// as the default value, so it cannot have a constraint.
+//
+// The variable is returned wrapped in an Optional group.
+func parseOptionalVariable(lex *lexer, varName string) (*pmpb.Segment, error) {
+	v := &pmpb.Variable{Name: varName}
+	var offset int
+	var tok Token
+
+	expected := []TokenType{TokenEq, TokenColon, TokenRightBrace}
+	switch {
+	case lex.Match(TokenEq):
+		if err := checkEscape(lex); err != nil {
+			return nil, err
+		}
+		if lex.Match(TokenLiteral) {
+			v.DefaultValue = lex.Prev().Value
+			offset, tok = lex.PrevOffset(), lex.Prev()
+		}
+		var err error
+		if v.Type, v.Regex, err = parseVariableConstraint(lex, varName); err != nil {
+			return nil, err
+		}
+		expected = []TokenType{TokenRightBrace}
+		if v.Type == "" && v.Regex == "" {
+			expected = []TokenType{TokenColon, TokenRightBrace}
+		}
+	case lex.Match(TokenColon):
+		if lex.Match(TokenLiteral) {
+			v.DefaultValue = lex.Prev().Value
+			offset, tok = lex.PrevOffset(), lex.Prev()
+		}
+		expected = []TokenType{TokenRightBrace}
+	}
+
+	if err := closeVariable(lex, varName, expected); err != nil {
+		return nil, err
+	}
+	if v.DefaultValue != "" {
+		if err := checkDefault(v); err != nil {
+			return nil, newError(offset, tok, nil, err)
+		}
+	}
+
+	return &pmpb.Segment{
+		Segment: &pmpb.Segment_Optional{
+			Optional: &pmpb.Optional{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Variable{Variable: v}},
+				},
 			},
 		},
 	}, nil
 }
+
+// closeVariable consumes the '}' closing a variable definition. The
+// expected token types are those valid in place of the '}'.
+func closeVariable(lex *lexer, varName string, expected []TokenType) error {
+	if lex.Match(TokenRightBrace) {
+		return nil
+	}
+	if lex.Peek().Type == TokenEOF {
+		err := fmt.Errorf("%w: variable '%s' must be closed with '}'", ErrUnexpectedEndOfInput, varName)
+		return errorAt(lex, expected, err)
+	}
+	err := fmt.Errorf("%w: expected %s in variable '%s', got: %s", ErrUnexpectedToken, describe(expected), varName, lex.Peek())
+	return errorAt(lex, expected, err)
+}
+
+// checkDefault checks that the default value of a variable satisfies
+// the variable's type and regex.
+func checkDefault(v *pmpb.Variable) error {
+	ok, err := vartype.Check(v.Type, v.DefaultValue)
+	if err == nil && ok && v.Regex != "" {
+		ok, err = regexp.MatchString(`^(?:`+v.Regex+`)$`, v.DefaultValue)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %q for variable '%s'", ErrInvalidDefault, v.DefaultValue, v.Name)
+	}
+	return nil
+}
+
+// variableOf returns the variable of a segment returned by parseVariable.
+func variableOf(seg *pmpb.Segment) *pmpb.Variable {
+	if opt := seg.GetOptional(); opt != nil {
+		return opt.Segments[0].GetVariable()
+	}
+	return seg.GetVariable()
+}
+
+// parseVariableConstraint parses an optional ':constraint' suffix of a variable.
+// A constraint that is an identifier names a registered variable type, e.g. "{id:int}".
+// Any other constraint is an RE2 regular expression, e.g. "{id:[0-9]+}".
+// To use an expression that looks like an identifier, wrap it in a group: "{id:(?:abc)}".
+func parseVariableConstraint(lex *lexer, varName string) (varType, varRegex string, err error) {
+	if !lex.Match(TokenColon) {
+		return "", "", nil
+	}
+	if !lex.Match(TokenLiteral) || lex.Prev().Value == "" {
+		if lex.Peek().Type == TokenEOF {
+			err := fmt.Errorf("%w: variable '%s' must have a type or regex after ':'", ErrUnexpectedEndOfInput, varName)
+			return "", "", errorAt(lex, []TokenType{TokenLiteral}, err)
+		}
+		err := fmt.Errorf("%w: variable '%s' must have a type or regex after ':'", ErrEmptyConstraint, varName)
+		return "", "", errorAt(lex, []TokenType{TokenLiteral}, err)
+	}
+	constraint := lex.Prev().Value
+
+	if vartype.IsIdentifier(constraint) {
+		if _, ok := vartype.Lookup(constraint); !ok {
+			err := fmt.Errorf("%w: %q in variable '%s'", ErrUnknownType, constraint, varName)
+			return "", "", newError(lex.PrevOffset(), lex.Prev(), nil, err)
+		}
+		return constraint, "", nil
+	}
+
+	if _, err := regexp.Compile(constraint); err != nil {
+		err = fmt.Errorf("%w in variable '%s': %w", ErrInvalidRegex, varName, err)
+		return "", "", newError(lex.PrevOffset(), lex.Prev(), nil, err)
+	}
+	return "", constraint, nil
+}
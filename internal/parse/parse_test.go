@@ -144,6 +144,308 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "/users/{id:int}/events/{day:date}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "users"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "id", Type: "int"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "events"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "day", Type: "date"}}},
+				},
+			},
+		},
+		{
+			input: "/files/{path=docs/*:string}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "files"}}},
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name: "path",
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "docs"}}},
+									{Segment: &pmpb.Segment_Star{}},
+								},
+								Type: "string",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/with/colon/a:b/c",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "with"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "colon"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "a:b"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "c"}}},
+				},
+			},
+		},
+		{
+			input: "/v1/{name=books/*}:publish",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "v1"}}},
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name: "name",
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "books"}}},
+									{Segment: &pmpb.Segment_Star{}},
+								},
+							},
+						},
+					},
+				},
+				Verb: "publish",
+			},
+		},
+		{
+			input: "/v1/books:batchGet",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "v1"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "books"}}},
+				},
+				Verb: "batchGet",
+			},
+		},
+		{
+			input: "/orders/{id:[0-9]{2,}}/{rest=**:/a/.*}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "orders"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "id", Regex: "[0-9]{2,}"}}},
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name:     "rest",
+								Segments: []*pmpb.Segment{{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}}},
+								Regex:    "/a/.*",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `/escaped/{name:a\}*=b}`,
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "escaped"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "name", Regex: `a\}*=b`}}},
+				},
+			},
+		},
+		{
+			input: "/files/{name}.{ext}/v{major:int}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "files"}}},
+					{
+						Segment: &pmpb.Segment_Composite{
+							Composite: &pmpb.Composite{
+								Parts: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "name"}}},
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "."}}},
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "ext"}}},
+								},
+							},
+						},
+					},
+					{
+						Segment: &pmpb.Segment_Composite{
+							Composite: &pmpb.Composite{
+								Parts: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "v"}}},
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "major", Type: "int"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/reports/{year}[/{month}[/{day}]]",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "reports"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "year"}}},
+					{
+						Segment: &pmpb.Segment_Optional{
+							Optional: &pmpb.Optional{
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "month"}}},
+									{
+										Segment: &pmpb.Segment_Optional{
+											Optional: &pmpb.Optional{
+												Segments: []*pmpb.Segment{
+													{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "day"}}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/users/{id}[/profile]/view",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "users"}}},
+					{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "id"}}},
+					{
+						Segment: &pmpb.Segment_Optional{
+							Optional: &pmpb.Optional{
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "profile"}}},
+								},
+							},
+						},
+					},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "view"}}},
+				},
+			},
+		},
+		{
+			input: "/api/(v1|v2)/{kind=(users|groups)}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "api"}}},
+					{Segment: &pmpb.Segment_Alternation{Alternation: &pmpb.Alternation{Values: []string{"v1", "v2"}}}},
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name: "kind",
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Alternation{Alternation: &pmpb.Alternation{Values: []string{"users", "groups"}}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/src/**/test/*.go",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "src"}}},
+					{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "test"}}},
+					{
+						Segment: &pmpb.Segment_Composite{
+							Composite: &pmpb.Composite{
+								Parts: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Star{}},
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: ".go"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/with/variable/{name=/some/**/path}/**",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "with"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "variable"}}},
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name: "name",
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "some"}}},
+									{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}},
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "path"}}},
+								},
+							},
+						},
+					},
+					{Segment: &pmpb.Segment_DoubleStar{DoubleStar: &pmpb.DoubleStar{}}},
+				},
+			},
+		},
+		{
+			input: `/q/a=b/files/\*.txt/\{id\}/a\\b/x\:y`,
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "q"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "a=b"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "files"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "*.txt"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "{id}"}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: `a\b`}}},
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "x:y"}}},
+				},
+			},
+		},
+		{
+			input: `/{key=k\=v/\(x\|y\)}`,
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{
+						Segment: &pmpb.Segment_Variable{
+							Variable: &pmpb.Variable{
+								Name: "key",
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "k=v"}}},
+									{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "(x|y)"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "/export/{id?=0:int}/{format?:json}/{page?}",
+			expected: pmpb.PathTemplate{
+				Segments: []*pmpb.Segment{
+					{Segment: &pmpb.Segment_Literal{Literal: &pmpb.Literal{Value: "export"}}},
+					{
+						Segment: &pmpb.Segment_Optional{
+							Optional: &pmpb.Optional{
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "id", Type: "int", DefaultValue: "0"}}},
+								},
+							},
+						},
+					},
+					{
+						Segment: &pmpb.Segment_Optional{
+							Optional: &pmpb.Optional{
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "format", DefaultValue: "json"}}},
+								},
+							},
+						},
+					},
+					{
+						Segment: &pmpb.Segment_Optional{
+							Optional: &pmpb.Optional{
+								Segments: []*pmpb.Segment{
+									{Segment: &pmpb.Segment_Variable{Variable: &pmpb.Variable{Name: "page"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for i := range tests {
@@ -172,23 +474,119 @@ func TestParseError(t *testing.T) {
 			err:   parse.ErrUnexpectedEndOfInput,
 		},
 		{
-			input: "/with/variable/**/after",
+			input: "/with/double/wildcard/a**",
 			err:   parse.ErrUnexpectedDoubleStar,
 		},
 		{
-			input: "/with/variable/{name=/some/other/**}/and/more",
+			input: "/with/variable/{name=/some/**.txt}",
 			err:   parse.ErrUnexpectedDoubleStar,
 		},
 		{
-			input: "/with/variable/{name=/some/other/**/path}/and/more",
-			err:   parse.ErrUnexpectedDoubleStar,
+			input: "/with/sub/variable/{var=/sub/{variable=value}}",
+			err:   parse.ErrSubVariable,
 		},
 		{
-			input: "/with/double/wildcard/**/**/",
-			err:   parse.ErrUnexpectedDoubleStar,
+			input: "/users/{id:nosuchtype}",
+			err:   parse.ErrUnknownType,
 		},
 		{
-			input: "/with/sub/variable/{var=/sub/{variable=value}}",
+			input: "/users/{id:",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/users/{id:[0-9}",
+			err:   parse.ErrInvalidRegex,
+		},
+		{
+			input: "/users/{id:}",
+			err:   parse.ErrEmptyConstraint,
+		},
+		{
+			input: "/users/{id:[0-9]+",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/files/{name}{ext}",
+			err:   parse.ErrAmbiguousVariables,
+		},
+		{
+			input: "/files/{name=a/*}.txt",
+			err:   parse.ErrInvalidComposite,
+		},
+		{
+			input: "/files/*{name}",
+			err:   parse.ErrAmbiguousVariables,
+		},
+		{
+			input: "/v1/books:",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/v1/books/:publish",
+			err:   parse.ErrInvalidVerb,
+		},
+		{
+			input: "/v1/books:pub*",
+			err:   parse.ErrInvalidVerb,
+		},
+		{
+			input: "/users/{id}[/profile",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/users/{id}[]",
+			err:   parse.ErrUnexpectedToken,
+		},
+		{
+			input: "/users/{id}[/]",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/users/{id}/profile]",
+			err:   parse.ErrUnexpectedToken,
+		},
+		{
+			input: "/users/{id}[/profile]more",
+			err:   parse.ErrUnexpectedToken,
+		},
+		{
+			input: "/api/(v1|)/items",
+			err:   parse.ErrEmptyAlternative,
+		},
+		{
+			input: "/api/()/items",
+			err:   parse.ErrEmptyAlternative,
+		},
+		{
+			input: "/api/(v1|v2",
+			err:   parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input: "/api/(v1/v2)",
+			err:   parse.ErrUnexpectedToken,
+		},
+		{
+			input: `/files/\q`,
+			err:   parse.ErrInvalidEscape,
+		},
+		{
+			input: `/files/abc\`,
+			err:   parse.ErrInvalidEscape,
+		},
+		{
+			input: `/api/(v1|\x)`,
+			err:   parse.ErrInvalidEscape,
+		},
+		{
+			input: "/export/{id?=abc:int}",
+			err:   parse.ErrInvalidDefault,
+		},
+		{
+			input: "/export/v{major?}",
+			err:   parse.ErrInvalidComposite,
+		},
+		{
+			input: "/export/{path=a/{x?}}",
 			err:   parse.ErrSubVariable,
 		},
 	}
@@ -198,6 +596,161 @@ func TestParseError(t *testing.T) {
 			_, err := parse.ParseTemplate(tests[i].input)
 			require.Error(t, err, "Parse should return an error")
 			require.ErrorIs(t, err, tests[i].err, "Expected error should match")
+
+			var perr *parse.ParseError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, tests[i].input, perr.Template)
+			require.LessOrEqual(t, perr.Offset, len(tests[i].input))
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		offset   int
+		token    parse.Token
+		expected []parse.TokenType
+		err      error
+	}{
+		{
+			input:    "users",
+			offset:   0,
+			token:    parse.Token{Type: parse.TokenLiteral, Value: "users"},
+			expected: []parse.TokenType{parse.TokenSlash},
+			err:      parse.ErrUnexpectedToken,
+		},
+		{
+			input:    "/users/{id",
+			offset:   10,
+			token:    parse.Token{Type: parse.TokenEOF},
+			expected: []parse.TokenType{parse.TokenEq, parse.TokenColon, parse.TokenQuestion, parse.TokenRightBrace},
+			err:      parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input:  "/users/{id:foo}",
+			offset: 11,
+			token:  parse.Token{Type: parse.TokenLiteral, Value: "foo"},
+			err:    parse.ErrUnknownType,
+		},
+		{
+			input:  "/files/{name}{ext}",
+			offset: 13,
+			token:  parse.Token{Type: parse.TokenLeftBrace},
+			err:    parse.ErrAmbiguousVariables,
+		},
+		{
+			input:  "/a/b**",
+			offset: 4,
+			token:  parse.Token{Type: parse.TokenDoubleStar},
+			err:    parse.ErrUnexpectedDoubleStar,
+		},
+		{
+			input:    "/a/(b|c",
+			offset:   7,
+			token:    parse.Token{Type: parse.TokenEOF},
+			expected: []parse.TokenType{parse.TokenPipe, parse.TokenRightParen},
+			err:      parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input:  "/v1/{name=shelves/{id}}",
+			offset: 18,
+			token:  parse.Token{Type: parse.TokenLeftBrace},
+			err:    parse.ErrSubVariable,
+		},
+		{
+			input:    "/{x?=a*}",
+			offset:   6,
+			token:    parse.Token{Type: parse.TokenStar},
+			expected: []parse.TokenType{parse.TokenColon, parse.TokenRightBrace},
+			err:      parse.ErrUnexpectedToken,
+		},
+		{
+			input:  "/{x?=abc:int}",
+			offset: 5,
+			token:  parse.Token{Type: parse.TokenLiteral, Value: "abc"},
+			err:    parse.ErrInvalidDefault,
+		},
+		{
+			input:  "/a/:go",
+			offset: 3,
+			token:  parse.Token{Type: parse.TokenColon},
+			err:    parse.ErrInvalidVerb,
+		},
+		{
+			input:  `/files/a\q`,
+			offset: 8,
+			token:  parse.Token{Type: parse.TokenUnknown, Value: `\q`},
+			err:    parse.ErrInvalidEscape,
+		},
+		{
+			input:    "/a/{x=}",
+			offset:   6,
+			token:    parse.Token{Type: parse.TokenRightBrace},
+			expected: []parse.TokenType{parse.TokenLiteral, parse.TokenStar, parse.TokenDoubleStar, parse.TokenLeftBrace, parse.TokenLeftParen},
+			err:      parse.ErrUnexpectedToken,
+		},
+		{
+			input:    "/a/{x=",
+			offset:   6,
+			token:    parse.Token{Type: parse.TokenEOF},
+			expected: []parse.TokenType{parse.TokenLiteral, parse.TokenStar, parse.TokenDoubleStar, parse.TokenLeftBrace, parse.TokenLeftParen},
+			err:      parse.ErrUnexpectedEndOfInput,
+		},
+		{
+			input:    "/a]",
+			offset:   2,
+			token:    parse.Token{Type: parse.TokenRightBracket},
+			expected: []parse.TokenType{parse.TokenSlash, parse.TokenLeftBracket, parse.TokenEOF},
+			err:      parse.ErrUnexpectedToken,
+		},
+	}
+
+	for i := range tests {
+		t.Run(tests[i].input, func(t *testing.T) {
+			_, err := parse.ParseTemplate(tests[i].input)
+			require.ErrorIs(t, err, tests[i].err)
+
+			var perr *parse.ParseError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, tests[i].offset, perr.Offset)
+			require.Equal(t, tests[i].token, perr.Token)
+			require.Equal(t, tests[i].expected, perr.Expected)
+		})
+	}
+}
+
+func TestParseErrorCaret(t *testing.T) {
+	_, err := parse.ParseTemplate("/users/{id")
+	var perr *parse.ParseError
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, "/users/{id\n          ^", perr.Caret())
+	require.Equal(t, `unexpected end of input: variable 'id' must be closed with '}' (at offset 10)`, perr.Error())
+
+	// The caret is placed by characters, not bytes
+	_, err = parse.ParseTemplate("/café/{id:foo}")
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, "/café/{id:foo}\n          ^", perr.Caret())
+}
+
+func TestEscapeLiteral(t *testing.T) {
+	values := []string{"plain", "a=b", "*.txt", "{id}", `a\b`, "x:y", "[opt]", "(a|b)", "**"}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			tmpl, err := parse.ParseTemplate("/" + parse.EscapeLiteral(value, false) + "/end")
+			require.NoError(t, err)
+			require.Equal(t, value, tmpl.Segments[0].GetLiteral().GetValue())
+
+			tmpl, err = parse.ParseTemplate("/{var=" + parse.EscapeLiteral(value, true) + "}")
+			require.NoError(t, err)
+			require.Equal(t, value, tmpl.Segments[0].GetVariable().GetSegments()[0].GetLiteral().GetValue())
+
+			// A literal in the last segment is not mistaken for a verb
+			tmpl, err = parse.ParseTemplate("/" + parse.EscapeLiteral(value, false))
+			require.NoError(t, err)
+			require.Empty(t, tmpl.Verb)
+			require.Equal(t, value, tmpl.Segments[0].GetLiteral().GetValue())
 		})
 	}
 }
@@ -5,27 +5,48 @@ import "fmt"
 type TokenType int
 
 const (
-	TokenUnknown    TokenType = iota
-	TokenSlash                // '/'
-	TokenStar                 // '*'
-	TokenDoubleStar           // '**'
-	TokenLiteral              // LITERAL
-	TokenLeftBrace            // '{'
-	TokenRightBrace           // '}'
-	TokenEq                   // '='
+	TokenUnknown      TokenType = iota
+	TokenSlash                  // '/'
+	TokenStar                   // '*'
+	TokenDoubleStar             // '**'
+	TokenLiteral                // LITERAL
+	TokenLeftBrace              // '{'
+	TokenRightBrace             // '}'
+	TokenEq                     // '='
+	TokenColon                  // ':'
+	TokenLeftBracket            // '['
+	TokenRightBracket           // ']'
+	TokenLeftParen              // '('
+	TokenRightParen             // ')'
+	TokenPipe                   // '|'
+	TokenQuestion               // '?'
 	TokenEOF
 )
 
 var tokenTypeNames = map[TokenType]string{
-	TokenUnknown:    "Unknown",
-	TokenSlash:      "/",
-	TokenStar:       "*",
-	TokenDoubleStar: "**",
-	TokenLiteral:    "LITERAL",
-	TokenLeftBrace:  "{",
-	TokenRightBrace: "}",
-	TokenEq:         "=",
-	TokenEOF:        "TokenEOF",
+	TokenUnknown:      "Unknown",
+	TokenSlash:        "/",
+	TokenStar:         "*",
+	TokenDoubleStar:   "**",
+	TokenLiteral:      "LITERAL",
+	TokenLeftBrace:    "{",
+	TokenRightBrace:   "}",
+	TokenEq:           "=",
+	TokenColon:        ":",
+	TokenLeftBracket:  "[",
+	TokenRightBracket: "]",
+	TokenLeftParen:    "(",
+	TokenRightParen:   ")",
+	TokenPipe:         "|",
+	TokenQuestion:     "?",
+	TokenEOF:          "TokenEOF",
+}
+
+func (t TokenType) String() string {
+	if name, ok := tokenTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenUnknown(%d)", int(t))
 }
 
 type Token struct {
@@ -0,0 +1,106 @@
+// Package rank orders templates by specificity, so that when several
+// templates match a path the most specific one can be chosen.
+package rank
+
+import (
+	"slices"
+
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Segment ranks, from the most to the least specific.
+const (
+	Literal = iota
+	Alternation
+	Composite
+	SubPattern // a variable with a sub-pattern, e.g. "{name=shelves/*}"
+	Single     // '*' or a single-segment variable, e.g. "{id}" or "{id=*}"
+	Optional
+	DoubleStar
+)
+
+// Segment returns the rank of a segment. A lower rank is more specific.
+func Segment(seg *pathmatchpb.Segment) int {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Literal:
+		return Literal
+	case *pathmatchpb.Segment_Alternation:
+		return Alternation
+	case *pathmatchpb.Segment_Composite:
+		return Composite
+	case *pathmatchpb.Segment_Variable:
+		if len(s.Variable.Segments) > 0 && !isSingleStar(s.Variable) {
+			return SubPattern
+		}
+		return Single
+	case *pathmatchpb.Segment_Star:
+		return Single
+	case *pathmatchpb.Segment_Optional:
+		return Optional
+	}
+	return DoubleStar
+}
+
+// isSingleStar reports whether v is "{x=*}", which matches the same
+// segments as "{x}" and so ranks the same.
+func isSingleStar(v *pathmatchpb.Variable) bool {
+	if len(v.Segments) != 1 {
+		return false
+	}
+	_, ok := v.Segments[0].Segment.(*pathmatchpb.Segment_Star)
+	return ok
+}
+
+// Compare compares the specificity of two templates. It returns a negative
+// number if a is more specific than b, a positive number if b is more
+// specific than a, and zero if neither is.
+//
+// The templates are compared segment by segment, and the first segment of
+// a different rank decides. Segments of the same rank that hold other
+// segments (sub-patterns, composites and optional groups) are compared by
+// their contents in the same way. If one template runs out of segments
+// first, it is the more specific one. Templates equal so far are ordered
+// by their verb: a template with a custom verb is more specific.
+func Compare(a, b *pathmatchpb.PathTemplate) int {
+	if c := compareSegments(a.GetSegments(), b.GetSegments()); c != 0 {
+		return c
+	}
+	switch {
+	case a.GetVerb() != "" && b.GetVerb() == "":
+		return -1
+	case a.GetVerb() == "" && b.GetVerb() != "":
+		return 1
+	}
+	return 0
+}
+
+// Sort sorts templates from the most to the least specific.
+// The order of equally specific templates is kept.
+func Sort(templates []*pathmatchpb.PathTemplate) {
+	slices.SortStableFunc(templates, Compare)
+}
+
+func compareSegments(a, b []*pathmatchpb.Segment) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareSegment(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareSegment(a, b *pathmatchpb.Segment) int {
+	ra, rb := Segment(a), Segment(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case SubPattern:
+		return compareSegments(a.GetVariable().Segments, b.GetVariable().Segments)
+	case Composite:
+		return compareSegments(a.GetComposite().Parts, b.GetComposite().Parts)
+	case Optional:
+		return compareSegments(a.GetOptional().Segments, b.GetOptional().Segments)
+	}
+	return 0
+}
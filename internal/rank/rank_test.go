@@ -0,0 +1,96 @@
+package rank_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/internal/rank"
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func mustParse(t *testing.T, s string) *pmpb.PathTemplate {
+	t.Helper()
+	tmpl, err := parse.ParseTemplate(s)
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int // sign of the result
+	}{
+		{a: "/users/me", b: "/users/{id}", expected: -1},
+		{a: "/users/{id}", b: "/users/*", expected: 0},
+		{a: "/users/{id}", b: "/users/{name}", expected: 0},
+		{a: "/users/{id}", b: "/users/{id:int}", expected: 0},
+		{a: "/v1/{name=shelves/*}", b: "/v1/{name}", expected: -1},
+		{a: "/v1/{name}", b: "/v1/**", expected: -1},
+		{a: "/users/{id=*}", b: "/users/{id}", expected: 0},
+		{a: "/users/{id=*}", b: "/users/*", expected: 0},
+		{a: "/users/me", b: "/users/{id=*}", expected: -1},
+		{a: "/v1/{name=shelves/*}", b: "/v1/{name=*}", expected: -1},
+		{a: "/v1/{name=shelves/*}", b: "/v1/{name=**}", expected: -1},
+		{a: "/v1/{name=shelves/*}", b: "/v1/{name=shelves/*/books/*}", expected: -1},
+		{a: "/api/(v1|v2)", b: "/api/{version}", expected: -1},
+		{a: "/api/(v1|v2)", b: "/api/v1", expected: 1},
+		{a: "/files/{name}.json", b: "/files/{name}.{ext}", expected: -1},
+		{a: "/files/{name}.{ext}", b: "/files/{name}", expected: -1},
+		{a: "/docs[/{page}]", b: "/docs/{page}", expected: 1},
+		{a: "/docs[/{page}]", b: "/docs/**", expected: -1},
+		{a: "/a/*/c", b: "/a/b/*", expected: 1},
+		{a: "/a", b: "/a/**", expected: -1},
+		{a: "/a/b", b: "/a", expected: 1},
+		{a: "/v1/{name}:publish", b: "/v1/{name}", expected: -1},
+		{a: "/v1/{name}:publish", b: "/v1/{name}:archive", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+			require.Equal(t, tt.expected, sign(rank.Compare(a, b)))
+			require.Equal(t, -tt.expected, sign(rank.Compare(b, a)))
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	input := []string{
+		"/**",
+		"/users/{id}/**",
+		"/users/{name}",
+		"/users/{id}",
+		"/users/me",
+		"/users/{path=me/*}",
+		"/users/*/posts",
+	}
+	expected := []string{
+		"/users/me",
+		"/users/{path=me/*}",
+		"/users/{name}",
+		"/users/{id}",
+		"/users/*/posts",
+		"/users/{id}/**",
+		"/**",
+	}
+
+	templates := make([]*pmpb.PathTemplate, len(input))
+	for i, s := range input {
+		templates[i] = mustParse(t, s)
+	}
+	rank.Sort(templates)
+	for i, s := range expected {
+		require.Equal(t, mustParse(t, s).String(), templates[i].String(), "position %d", i)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package vartype
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownType     = errors.New("unknown variable type")
+	ErrInvalidTypeName = errors.New("invalid variable type name")
+	ErrDuplicateType   = errors.New("variable type is already registered")
+)
+
+// CheckFunc reports whether a captured value belongs to a variable type.
+type CheckFunc func(value string) bool
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]CheckFunc{
+		"string": isString,
+		"int":    isInt,
+		"uuid":   isUUID,
+		"date":   isDate,
+	}
+)
+
+// Register adds a named variable type to the registry.
+// The name must be a valid identifier and must not be registered yet.
+func Register(name string, check CheckFunc) error {
+	if !IsIdentifier(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTypeName, name)
+	}
+	if check == nil {
+		return fmt.Errorf("check function for type %q cannot be nil", name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateType, name)
+	}
+	registry[name] = check
+	return nil
+}
+
+// Lookup returns the check function registered for the given type name.
+func Lookup(name string) (CheckFunc, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	check, ok := registry[name]
+	return check, ok
+}
+
+// Check reports whether value satisfies the named type.
+// An empty type name accepts any value.
+func Check(name, value string) (bool, error) {
+	if name == "" {
+		return true, nil
+	}
+	check, ok := Lookup(name)
+	if !ok {
+		return false, fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+	return check(value), nil
+}
+
+// examples holds a sample value of each built-in type.
+var examples = map[string]string{
+	"string": "x",
+	"int":    "1",
+	"uuid":   "123e4567-e89b-12d3-a456-426614174000",
+	"date":   "2000-01-01",
+}
+
+// Example returns a sample value of a built-in type.
+// It reports false for the types registered with Register.
+func Example(name string) (string, bool) {
+	value, ok := examples[name]
+	return value, ok
+}
+
+// IsIdentifier reports whether s is a non-empty identifier made of ASCII
+// letters, digits and underscores, not starting with a digit.
+func IsIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isString(value string) bool {
+	return value != ""
+}
+
+func isInt(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err == nil
+}
+
+// isUUID accepts the canonical 8-4-4-4-12 hexadecimal form.
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isHex(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDate accepts calendar dates in the YYYY-MM-DD form.
+func isDate(value string) bool {
+	_, err := time.Parse(time.DateOnly, value)
+	return err == nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
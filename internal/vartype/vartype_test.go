@@ -0,0 +1,70 @@
+package vartype_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch/internal/vartype"
+)
+
+func TestRegister(t *testing.T) {
+	isLower := func(s string) bool { return s != "" && strings.ToLower(s) == s }
+	require.NoError(t, vartype.Register("lower", isLower))
+
+	ok, err := vartype.Check("lower", "abc")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = vartype.Check("lower", "ABC")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.ErrorIs(t, vartype.Register("lower", isLower), vartype.ErrDuplicateType)
+	require.ErrorIs(t, vartype.Register("int", isLower), vartype.ErrDuplicateType)
+	require.ErrorIs(t, vartype.Register("1st", isLower), vartype.ErrInvalidTypeName)
+	require.ErrorIs(t, vartype.Register("with-dash", isLower), vartype.ErrInvalidTypeName)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		typ   string
+		value string
+		ok    bool
+	}{
+		{typ: "", value: "anything", ok: true},
+		{typ: "string", value: "x", ok: true},
+		{typ: "string", value: "", ok: false},
+		{typ: "int", value: "-12", ok: true},
+		{typ: "int", value: "1.5", ok: false},
+		{typ: "uuid", value: "123E4567-E89B-12D3-A456-426614174000", ok: true},
+		{typ: "uuid", value: "123e4567-e89b-12d3-a456-42661417400g", ok: false},
+		{typ: "date", value: "2024-01-31", ok: true},
+		{typ: "date", value: "2024-13-01", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ+"_"+tt.value, func(t *testing.T) {
+			ok, err := vartype.Check(tt.typ, tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.ok, ok)
+		})
+	}
+
+	_, err := vartype.Check("nosuchtype", "x")
+	require.ErrorIs(t, err, vartype.ErrUnknownType)
+}
+
+func TestExample(t *testing.T) {
+	for _, typ := range []string{"string", "int", "uuid", "date"} {
+		value, ok := vartype.Example(typ)
+		require.True(t, ok, typ)
+
+		ok, err := vartype.Check(typ, value)
+		require.NoError(t, err)
+		require.True(t, ok, typ)
+	}
+
+	_, ok := vartype.Example("nosuchtype")
+	require.False(t, ok)
+}
@@ -0,0 +1,36 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Intersect returns a template matching exactly the paths matched by both
+// parsed templates, and whether they match a common path at all. Literals
+// are compared case-sensitively.
+//
+// Variables carry over from either template: a wildcard facing "{p}"
+// becomes "{p}", and where both templates have a variable, the variable
+// of a is kept. A variable facing a literal captures it: "/items/{id}"
+// and "/items/new" give "/items/{id=new}".
+//
+// Example:
+//
+//	deny, _ := pathmatch.ParseTemplate("/projects/*/secrets/**")
+//	grant, _ := pathmatch.ParseTemplate("/projects/{p}/**")
+//	common, ok := pathmatch.Intersect(deny, grant)
+//	// ok == true, common is "/projects/{p}/secrets/**"
+//
+// Some intersections have no template: the paths matched by "/**/a/**"
+// and "/**/b/**" contain both "a" and "b", in either order. Intersect then
+// returns a nil template, with ok reporting whether the templates are
+// known to match a common path. It does so as well when two different
+// variable constraints or composite segments meet, such as "{id:int}"
+// and "{id:[0-9]{3}}", or when a verb must be appended to a '*' or '**'.
+func Intersect(a, b *pathmatchpb.PathTemplate) (*pathmatchpb.PathTemplate, bool) {
+	common, ok, err := lang.Intersect(a, b)
+	if err != nil {
+		return nil, ok
+	}
+	return common, ok
+}
@@ -0,0 +1,278 @@
+// Package lint inspects a set of templates and reports likely mistakes,
+// such as templates that can never be reached or variables whose values
+// overwrite each other.
+//
+// Example:
+//
+//	diags := lint.Lint(templates)
+//	for _, d := range diags {
+//		log.Println(d)
+//	}
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/tsdkv/pathmatch"
+	"github.com/tsdkv/pathmatch/internal/lang"
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/normalize"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Severity tells how likely a diagnostic is to be a bug.
+type Severity int
+
+const (
+	// Warning reports a template that works but is likely a mistake.
+	Warning Severity = iota
+	// Error reports a template that cannot work as written.
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Code identifies the kind of problem a diagnostic reports.
+type Code string
+
+const (
+	// DuplicateVariable reports a variable name used twice in a template.
+	// When both variables match, the last value wins, unless
+	// WithKeepFirstVariable is used.
+	DuplicateVariable Code = "duplicate-variable"
+	// Shadowed reports a template whose paths are all matched by an earlier
+	// template with a '**' segment, so that it is never reached when the
+	// first matching template is chosen.
+	Shadowed Code = "shadowed"
+	// CaseOnly reports a template that differs from an earlier one only by
+	// case, such as "/Users/{id}" and "/users/{id}".
+	CaseOnly Code = "case-only"
+	// EmptyVariableName reports a variable name made only of spaces or
+	// underscores, such as "{ }" or "{_}".
+	EmptyVariableName Code = "empty-variable-name"
+	// InvalidVariableName reports a variable name that is not an identifier
+	// or a dot-separated path of identifiers, such as "{user-id}", which
+	// cannot name a field of a request message.
+	InvalidVariableName Code = "invalid-variable-name"
+)
+
+// Diagnostic is a problem found in a template of the set.
+type Diagnostic struct {
+	Code     Code
+	Severity Severity
+	// Template is the index of the offending template in the set.
+	Template int
+	// Segment is the index of the offending segment in the template's
+	// Segments, where a segment nested in an optional group is reported at
+	// the index of the group. It is -1 when no segment is at fault, e.g.
+	// when two templates differ only by the case of their verb.
+	Segment int
+	// Related is the index of the earlier template involved, for Shadowed
+	// and CaseOnly diagnostics, and -1 otherwise.
+	Related int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("template %d, segment %d: %s: %s (%s)", d.Template, d.Segment, d.Severity, d.Message, d.Code)
+}
+
+// Lint returns the diagnostics of a set of templates, ordered by template
+// and then by segment. The options are those used to match paths against
+// the templates, e.g. WithCaseInsensitive makes "/Users/**" shadow
+// "/users/{id}". Nil templates are skipped.
+func Lint(templates []*pathmatchpb.PathTemplate, opts ...pathmatch.MatchOption) []Diagnostic {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+
+	var diags []Diagnostic
+	for j, tmpl := range templates {
+		if tmpl == nil {
+			continue
+		}
+		var found []Diagnostic
+		found = append(found, variables(j, tmpl)...)
+		for i, earlier := range templates[:j] {
+			if earlier == nil {
+				continue
+			}
+			if d, ok := shadowed(i, j, earlier, tmpl, mopts); ok {
+				found = append(found, d)
+			}
+			if d, ok := caseOnly(i, j, earlier, tmpl); ok {
+				found = append(found, d)
+			}
+		}
+		diags = append(diags, sorted(found)...)
+	}
+	return diags
+}
+
+// variables reports the duplicate and badly named variables of a template.
+func variables(index int, tmpl *pathmatchpb.PathTemplate) []Diagnostic {
+	var diags []Diagnostic
+	report := func(code Code, severity Severity, segment int, format string, args ...any) {
+		diags = append(diags, Diagnostic{
+			Code:     code,
+			Severity: severity,
+			Template: index,
+			Segment:  segment,
+			Related:  -1,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	defined := make(map[string]int)
+	for i, seg := range tmpl.GetSegments() {
+		for _, name := range variableNames(seg) {
+			switch {
+			case isEmptyName(name):
+				report(EmptyVariableName, Error, i, "variable name %q looks empty", name)
+			case !isFieldPath(name):
+				report(InvalidVariableName, Warning, i, "variable name %q is not an identifier", name)
+			}
+			if first, ok := defined[name]; ok {
+				report(DuplicateVariable, Warning, i, "variable %q is already defined in segment %d", name, first)
+				continue
+			}
+			defined[name] = i
+		}
+	}
+	return diags
+}
+
+// variableNames returns the names of the variables of a segment, including
+// those nested in composite segments and optional groups, in order.
+func variableNames(seg *pathmatchpb.Segment) []string {
+	switch s := seg.Segment.(type) {
+	case *pathmatchpb.Segment_Variable:
+		return []string{s.Variable.Name}
+	case *pathmatchpb.Segment_Composite:
+		var names []string
+		for _, part := range s.Composite.Parts {
+			names = append(names, variableNames(part)...)
+		}
+		return names
+	case *pathmatchpb.Segment_Optional:
+		var names []string
+		for _, inner := range s.Optional.Segments {
+			names = append(names, variableNames(inner)...)
+		}
+		return names
+	}
+	return nil
+}
+
+func isEmptyName(name string) bool {
+	return strings.Trim(name, " \t_") == ""
+}
+
+// isFieldPath reports whether name is a dot-separated path of identifiers,
+// such as "id" or "book.name".
+func isFieldPath(name string) bool {
+	for _, field := range strings.Split(name, ".") {
+		if field == "" {
+			return false
+		}
+		for i, r := range field {
+			if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// shadowed reports whether the earlier template a, with a '**' segment,
+// matches every path matched by b.
+func shadowed(i, j int, a, b *pathmatchpb.PathTemplate, opts *match.MatchOptions) (Diagnostic, bool) {
+	if !hasDoubleStar(a.GetSegments()) || !lang.Covers(a, b, opts) {
+		return Diagnostic{}, false
+	}
+	return Diagnostic{
+		Code:     Shadowed,
+		Severity: Error,
+		Template: j,
+		Segment:  firstDifference(a, b),
+		Related:  i,
+		Message:  fmt.Sprintf("every path is matched by template %d %q", i, parse.Format(a)),
+	}, true
+}
+
+// caseOnly reports whether the templates a and b differ only by case.
+func caseOnly(i, j int, a, b *pathmatchpb.PathTemplate) (Diagnostic, bool) {
+	textA, textB := parse.Format(normalize.Normalize(a)), parse.Format(normalize.Normalize(b))
+	if textA == textB || !strings.EqualFold(textA, textB) {
+		return Diagnostic{}, false
+	}
+	return Diagnostic{
+		Code:     CaseOnly,
+		Severity: Warning,
+		Template: j,
+		Segment:  firstDifference(a, b),
+		Related:  i,
+		Message:  fmt.Sprintf("differs from template %d %q only by case", i, textA),
+	}, true
+}
+
+func hasDoubleStar(segs []*pathmatchpb.Segment) bool {
+	for _, seg := range segs {
+		switch s := seg.Segment.(type) {
+		case *pathmatchpb.Segment_DoubleStar:
+			return true
+		case *pathmatchpb.Segment_Variable:
+			if hasDoubleStar(s.Variable.Segments) {
+				return true
+			}
+		case *pathmatchpb.Segment_Optional:
+			if hasDoubleStar(s.Optional.Segments) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstDifference returns the index of the first segment of b that differs
+// from the segment of a at the same index, or -1 if there is none.
+func firstDifference(a, b *pathmatchpb.PathTemplate) int {
+	segsA, segsB := a.GetSegments(), b.GetSegments()
+	for k, seg := range segsB {
+		if k >= len(segsA) || formatSegment(segsA[k]) != formatSegment(seg) {
+			return k
+		}
+	}
+	return -1
+}
+
+func formatSegment(seg *pathmatchpb.Segment) string {
+	return parse.Format(&pathmatchpb.PathTemplate{Segments: []*pathmatchpb.Segment{seg}})
+}
+
+// sorted orders the diagnostics of a template by segment, keeping the
+// order of diagnostics of a segment. Diagnostics with no segment go last.
+func sorted(diags []Diagnostic) []Diagnostic {
+	key := func(d Diagnostic) int {
+		if d.Segment < 0 {
+			return math.MaxInt
+		}
+		return d.Segment
+	}
+	slices.SortStableFunc(diags, func(x, y Diagnostic) int {
+		return cmp.Compare(key(x), key(y))
+	})
+	return diags
+}
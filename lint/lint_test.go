@@ -0,0 +1,137 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tsdkv/pathmatch"
+	"github.com/tsdkv/pathmatch/internal/parse"
+	"github.com/tsdkv/pathmatch/lint"
+	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+func mustParseTemplates(t *testing.T, patterns ...string) []*pmpb.PathTemplate {
+	t.Helper()
+	templates := make([]*pmpb.PathTemplate, len(patterns))
+	for i, pattern := range patterns {
+		tmpl, err := parse.ParseTemplate(pattern)
+		require.NoError(t, err)
+		templates[i] = tmpl
+	}
+	return templates
+}
+
+type diag struct {
+	code     lint.Code
+	severity lint.Severity
+	template int
+	segment  int
+	related  int
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates []string
+		opts      []pathmatch.MatchOption
+		expected  []diag
+	}{
+		{
+			name:      "clean set",
+			templates: []string{"/users/{id}", "/users/{id}/books/{book.name}", "/files/**", "/static/{path=**}"},
+		},
+		{
+			name:      "duplicate variable",
+			templates: []string{"/users/{id}/books/{id}"},
+			expected:  []diag{{lint.DuplicateVariable, lint.Warning, 0, 3, -1}},
+		},
+		{
+			name:      "duplicate variable in composite and optional group",
+			templates: []string{"/files/{name}.{name}", "/users/{id}[/books/{id}]"},
+			expected: []diag{
+				{lint.DuplicateVariable, lint.Warning, 0, 1, -1},
+				{lint.DuplicateVariable, lint.Warning, 1, 2, -1},
+			},
+		},
+		{
+			name:      "shadowed by double star",
+			templates: []string{"/users/**", "/users/{id}/books", "/users"},
+			expected: []diag{
+				{lint.Shadowed, lint.Error, 1, 1, 0},
+				{lint.Shadowed, lint.Error, 2, -1, 0},
+			},
+		},
+		{
+			name:      "shadowed by double star variable",
+			templates: []string{"/v1/{name=shelves/**}", "/v1/shelves/{shelf}:get"},
+			expected:  []diag{{lint.Shadowed, lint.Error, 1, 1, 0}},
+		},
+		{
+			name:      "later double star does not shadow",
+			templates: []string{"/users/{id}", "/users/**"},
+		},
+		{
+			name:      "overlap is not shadowing",
+			templates: []string{"/users/**/books", "/users/{id}"},
+		},
+		{
+			name:      "shadowed ignoring case",
+			templates: []string{"/Files/**", "/files/{name}"},
+			opts:      []pathmatch.MatchOption{pathmatch.WithCaseInsensitive()},
+			expected:  []diag{{lint.Shadowed, lint.Error, 1, 0, 0}},
+		},
+		{
+			name:      "differs only by case",
+			templates: []string{"/users/{id}/Books", "/users/{id}/books", "/users/{id}/books:Get", "/users/{id}/books:get"},
+			expected: []diag{
+				{lint.CaseOnly, lint.Warning, 1, 2, 0},
+				{lint.CaseOnly, lint.Warning, 3, -1, 2},
+			},
+		},
+		{
+			name:      "shadowed and differs only by case",
+			templates: []string{"/Users/**", "/users/**"},
+			opts:      []pathmatch.MatchOption{pathmatch.WithCaseInsensitive()},
+			expected: []diag{
+				{lint.Shadowed, lint.Error, 1, 0, 0},
+				{lint.CaseOnly, lint.Warning, 1, 0, 0},
+			},
+		},
+		{
+			name:      "empty-looking variable names",
+			templates: []string{"/users/{ }", "/users/{_}/books/{__}"},
+			expected: []diag{
+				{lint.EmptyVariableName, lint.Error, 0, 1, -1},
+				{lint.EmptyVariableName, lint.Error, 1, 1, -1},
+				{lint.EmptyVariableName, lint.Error, 1, 3, -1},
+			},
+		},
+		{
+			name:      "non-identifier variable names",
+			templates: []string{"/users/{user-id}", "/users/{1st}/{a..b}", "/users/{id_2}/{book.name}/{é}"},
+			expected: []diag{
+				{lint.InvalidVariableName, lint.Warning, 0, 1, -1},
+				{lint.InvalidVariableName, lint.Warning, 1, 1, -1},
+				{lint.InvalidVariableName, lint.Warning, 1, 2, -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := lint.Lint(mustParseTemplates(t, tt.templates...), tt.opts...)
+			var got []diag
+			for _, d := range diags {
+				require.NotEmpty(t, d.Message)
+				got = append(got, diag{d.Code, d.Severity, d.Template, d.Segment, d.Related})
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	diags := lint.Lint(mustParseTemplates(t, "/files/**", "/files/{name}"))
+	require.Len(t, diags, 1)
+	require.Equal(t, `template 1, segment 1: error: every path is matched by template 0 "/files/**" (shadowed)`, diags[0].String())
+}
@@ -1,12 +1,35 @@
 package pathmatch
 
 import (
+	"net/url"
+
 	"github.com/tsdkv/pathmatch/internal/match"
 	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
 )
 
 type MatchOption func(*match.MatchOptions)
 
+// UnescapingMode controls how percent-encoded path segments are decoded
+// before they are matched against a template and captured. The modes follow
+// the grpc-gateway conventions.
+type UnescapingMode = match.UnescapingMode
+
+const (
+	// UnescapeNone matches and captures the path exactly as given (default).
+	UnescapeNone = match.UnescapeNone
+	// UnescapeAllExceptReserved decodes every percent-encoded character except
+	// the reserved characters of RFC 6570, such as "%2F" ('/') and "%3A" (':').
+	UnescapeAllExceptReserved = match.UnescapeAllExceptReserved
+	// UnescapeAll decodes every percent-encoded character. Multi-segment
+	// captures such as "{path=**}" keep "%2F" encoded, unless
+	// WithDecodedSlashes is used.
+	UnescapeAll = match.UnescapeAll
+)
+
+// ErrMalformedPath is returned when a path contains an invalid
+// percent-encoding and an unescaping mode other than UnescapeNone is used.
+var ErrMalformedPath = match.ErrMalformedPath
+
 // WithCaseInsensitive sets the match options to be case-insensitive.
 func WithCaseInsensitive() MatchOption {
 	return func(opts *match.MatchOptions) {
@@ -23,6 +46,25 @@ func WithKeepFirstVariable() MatchOption {
 	}
 }
 
+// WithUnescaping treats the path as percent-encoded, such as url.URL.RawPath,
+// and decodes its segments with the given mode. The path is split on '/'
+// before decoding, so an encoded slash ("%2F") never separates segments.
+// Literals are compared with, and variables capture, the decoded segments.
+func WithUnescaping(mode UnescapingMode) MatchOption {
+	return func(opts *match.MatchOptions) {
+		opts.Unescape = mode
+	}
+}
+
+// WithDecodedSlashes makes multi-segment captures decode "%2F" too
+// when used with UnescapeAll. By default they keep it encoded, so that
+// "/a%2Fb/c" captured by "{path=**}" is "/a%2Fb/c" rather than "/a/b/c".
+func WithDecodedSlashes() MatchOption {
+	return func(opts *match.MatchOptions) {
+		opts.DecodeSlashes = true
+	}
+}
+
 // Matches path to a parsed template path
 // path cant contain wildcards or variables, only literal segments
 //
@@ -39,3 +81,12 @@ func Match(template *pathmatchpb.PathTemplate, path string, opts ...MatchOption)
 
 	return match.StrictMatch(template, path, mopts)
 }
+
+// MatchURL matches the escaped path of u against a parsed template.
+// It uses u.EscapedPath, so percent-encoded slashes are not mistaken for
+// segment separators, and decodes segments with UnescapeAllExceptReserved
+// unless another mode is set with WithUnescaping.
+func MatchURL(template *pathmatchpb.PathTemplate, u *url.URL, opts ...MatchOption) (matched bool, vars map[string]string, err error) {
+	opts = append([]MatchOption{WithUnescaping(UnescapeAllExceptReserved)}, opts...)
+	return Match(template, u.EscapedPath(), opts...)
+}
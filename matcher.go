@@ -0,0 +1,61 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Captures holds the variables captured by Matcher.MatchInto. It can be
+// reused from one match to the next, so that matching does not allocate.
+// The captured values share memory with the matched path.
+type Captures = match.Captures
+
+// Matcher is a template compiled for matching many paths. It gives the same
+// results as Match with the options it was compiled with, but validates the
+// template once and, with MatchInto, matches without allocating.
+//
+// A Matcher is safe for concurrent use; a Captures value is not.
+type Matcher struct {
+	p *match.Program
+}
+
+// Compile validates a parsed template and compiles it for matching whole
+// paths with the given options.
+//
+// Example:
+//
+//	m, err := pathmatch.Compile(tmpl, pathmatch.WithCaseInsensitive())
+//	var caps pathmatch.Captures
+//	if ok, _ := m.MatchInto("/users/alice", &caps); ok {
+//		id, _ := caps.Get("id")
+//	}
+func Compile(template *pathmatchpb.PathTemplate, opts ...MatchOption) (*Matcher, error) {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+
+	p, err := match.Compile(template, mopts)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{p: p}, nil
+}
+
+// Match matches the whole path and returns the captured variables in a map,
+// as the Match function does.
+func (m *Matcher) Match(path string) (matched bool, vars map[string]string, err error) {
+	var caps Captures
+	if matched, err = m.p.MatchInto(path, &caps); !matched || err != nil {
+		return false, nil, err
+	}
+	return true, caps.Map(), nil
+}
+
+// MatchInto matches the whole path and stores the captured variables in caps,
+// replacing those of a previous match. Once caps has grown to fit the template
+// and the path, it does not allocate, except for percent-decoded values and
+// sub-pattern captures over repeated slashes.
+func (m *Matcher) MatchInto(path string, caps *Captures) (bool, error) {
+	return m.p.MatchInto(path, caps)
+}
@@ -0,0 +1,41 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/normalize"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Normalize returns a copy of a parsed template in a canonical form, so that
+// templates written differently but matching the same paths are equal
+// protos with the same captures. For example "{x=*}" becomes "{x}",
+// "(v2|v1)" becomes "(v1|v2)", "(v1)" becomes "v1" and "/**/**" becomes
+// "/**".
+//
+// The values of an alternation inside a composite segment keep their
+// order: the first value matching decides what the next parts capture, as
+// "(ab|a){x}" captures "c" from "abc" while "(a|ab){x}" captures "bc".
+func Normalize(template *pathmatchpb.PathTemplate) *pathmatchpb.PathTemplate {
+	return normalize.Normalize(template)
+}
+
+// Equal reports whether two parsed templates are equal once normalized.
+// Variable names, constraints and verbs are significant, and literals are
+// compared case-sensitively.
+//
+// Example:
+//
+//	a, _ := pathmatch.ParseTemplate("/users/{id=*}")
+//	b, _ := pathmatch.ParseTemplate("/users/{id}")
+//	pathmatch.Equal(a, b) // true
+func Equal(a, b *pathmatchpb.PathTemplate) bool {
+	return normalize.Equal(a, b)
+}
+
+// Fingerprint returns a 64-bit hash of a parsed template, for use as a
+// deduplication or cache key. Templates that are Equal have the same
+// fingerprint. The hash is computed from the normalized template's Format
+// text, so it is stable across processes and does not depend on proto
+// serialization.
+func Fingerprint(template *pathmatchpb.PathTemplate) uint64 {
+	return normalize.Fingerprint(template)
+}
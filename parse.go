@@ -5,13 +5,93 @@ import (
 	pmpb "github.com/tsdkv/pathmatch/pathmatchpb/v1"
 )
 
+// ParseError is the error returned by ParseTemplate for an invalid template.
+// It holds the byte offset and the token at which parsing failed, the token
+// types that were expected there, if any, and an error wrapping one of the
+// sentinel errors below, so that errors.Is reports the kind of failure.
+//
+// Its Caret method renders the template with a '^' under the offending
+// position, for showing the error to the author of the template:
+//
+//	/users/{id
+//	          ^
+type ParseError = parse.ParseError
+
+// Token is a token of the template syntax, as found by ParseTemplate.
+type Token = parse.Token
+
+// TokenType is the type of a Token.
+type TokenType = parse.TokenType
+
+const (
+	TokenUnknown      = parse.TokenUnknown // an invalid escape sequence
+	TokenSlash        = parse.TokenSlash
+	TokenStar         = parse.TokenStar
+	TokenDoubleStar   = parse.TokenDoubleStar
+	TokenLiteral      = parse.TokenLiteral
+	TokenLeftBrace    = parse.TokenLeftBrace
+	TokenRightBrace   = parse.TokenRightBrace
+	TokenEq           = parse.TokenEq
+	TokenColon        = parse.TokenColon
+	TokenLeftBracket  = parse.TokenLeftBracket
+	TokenRightBracket = parse.TokenRightBracket
+	TokenLeftParen    = parse.TokenLeftParen
+	TokenRightParen   = parse.TokenRightParen
+	TokenPipe         = parse.TokenPipe
+	TokenQuestion     = parse.TokenQuestion
+	TokenEOF          = parse.TokenEOF
+)
+
+// The causes of a ParseError, with ErrUnknownType, to be tested with
+// errors.Is.
+var (
+	ErrUnexpectedEndOfInput = parse.ErrUnexpectedEndOfInput
+	ErrUnexpectedToken      = parse.ErrUnexpectedToken
+	ErrUnexpectedDoubleStar = parse.ErrUnexpectedDoubleStar
+	ErrSubVariable          = parse.ErrSubVariable
+	ErrInvalidRegex         = parse.ErrInvalidRegex
+	ErrEmptyConstraint      = parse.ErrEmptyConstraint
+	ErrInvalidComposite     = parse.ErrInvalidComposite
+	ErrAmbiguousVariables   = parse.ErrAmbiguousVariables
+	ErrInvalidVerb          = parse.ErrInvalidVerb
+	ErrEmptyAlternative     = parse.ErrEmptyAlternative
+	ErrInvalidEscape        = parse.ErrInvalidEscape
+	ErrInvalidDefault       = parse.ErrInvalidDefault
+)
+
 // ParseTemplate parses a path template string into a structured PathTemplate object.
 //
 // The template string must start with a '/' and may contain:
 //   - Literal segments (e.g., "/users")
 //   - Wildcard segments: '*' matches any single path segment
-//   - Double wildcard: '**' matches zero or more segments, but only as a full segment and only at the end
+//   - Double wildcard: '**' matches zero or more segments, only as a full segment but anywhere in the path
 //   - Variables: '{name}' for a single segment, or '{name=pattern}' where pattern is a sequence of segments
+//   - Variable constraints: '{name:type}' for a registered type (see RegisterType),
+//     or '{name:regex}' for an RE2 expression the captured value must match in full
+//   - Composite segments: literals and variables within one segment, e.g. '{name}.{ext}'
+//   - Optional groups: '/users/{id}[/profile]'
+//   - Alternations of literals: '/api/(v1|v2)'
+//   - Optional variables with defaults: '{format?=json}', or the shorthand '{format?:json}'
+//   - A trailing custom verb: '/v1/{name=books/*}:publish'
+//
+// Reserved characters can be used in literals by escaping them with a
+// backslash, e.g. `/files/\*.txt`. The '=' and ':' characters are only
+// reserved inside variable definitions.
+//
+// An invalid template yields a *ParseError.
 func ParseTemplate(s string) (*pmpb.PathTemplate, error) {
 	return parse.ParseTemplate(s)
 }
+
+// Format renders a parsed template back in the template syntax, so that
+// ParseTemplate(Format(t)) returns a template equal to t. The text is
+// canonical: reserved characters in literals are escaped, empty segments
+// are dropped, and "{format?:json}" is rendered as "{format?=json}".
+//
+// Example:
+//
+//	tmpl, _ := pathmatch.ParseTemplate("/files//{name}.{ext}/")
+//	pathmatch.Format(tmpl) // "/files/{name}.{ext}"
+func Format(template *pmpb.PathTemplate) string {
+	return parse.Format(template)
+}
@@ -25,13 +25,27 @@ const (
 // This structure is used to store and interpret path matching rules.
 //
 // The grammar for path templates can be summarized as:
-// Template = "/" [ Segments ] ;
-// Segments = Segment { "/" Segment } ;
-// Segment  = "*" | "**" | LITERAL | Variable ;
-// Variable = "{" LITERAL [ "=" Segments ] "}" ;  // LITERAL here is the variable name
+// Template = "/" [ Segments ] [ ":" VERB ] ;
+// Segments = Segment { "/" Segment | Optional } ;
+// Optional = "[" "/" Segments "]" ;  // e.g. "[/profile]", may be nested
+// Segment  = "*" | "**" | LITERAL | Variable | Alternation | Composite ;
+// Alternation = "(" LITERAL { "|" LITERAL } ")" ;  // e.g. "(v1|v2)"
+// Composite = Part Part { Part } ;  // e.g. "{name}.{ext}", no two adjacent variables
+// Part     = LITERAL | Variable | Alternation | "*" ;  // a Variable here cannot have "=" Segments
+// Variable = "{" LITERAL [ "=" Segments ] [ ":" ( TYPE | REGEX ) ] "}"  // LITERAL here is the variable name
+//
+//	| "{" LITERAL "?" [ "=" DEFAULT [ ":" ( TYPE | REGEX ) ] | ":" DEFAULT ] "}" ;
 //
 // Examples:
 // - /users/{id}/profile
+// - /users/{id:int}/profile
+// - /orders/{id:[0-9]+}
+// - /files/{name}.{ext}
+// - /v1/{name=books/*}:publish
+// - /reports/{year}[/{month}[/{day}]]
+// - /api/(v1|v2)/{kind=(users|groups)}/{id}
+// - /export/{id}/{format?=json}
+// - /src/**/test/*.go
 // - /files/**
 // - /archive/{year}/{month=**}
 // - /v1/books/{book_id}
@@ -39,7 +53,11 @@ const (
 type PathTemplate struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The path template is represented as a sequence of segments.
-	Segments      []*Segment `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+	Segments []*Segment `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+	// Optional. A custom verb following the last segment, e.g., "publish" in
+	// "/v1/{name=books/*}:publish", as used by google.api.http rules.
+	// A template with a verb only matches paths ending in ":" followed by the verb.
+	Verb          string `protobuf:"bytes,2,opt,name=verb,proto3" json:"verb,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,6 +99,13 @@ func (x *PathTemplate) GetSegments() []*Segment {
 	return nil
 }
 
+func (x *PathTemplate) GetVerb() string {
+	if x != nil {
+		return x.Verb
+	}
+	return ""
+}
+
 // Segment represents a single component of a path template.
 type Segment struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -90,6 +115,9 @@ type Segment struct {
 	//	*Segment_Variable
 	//	*Segment_Star
 	//	*Segment_DoubleStar
+	//	*Segment_Composite
+	//	*Segment_Optional
+	//	*Segment_Alternation
 	Segment       isSegment_Segment `protobuf_oneof:"segment"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -168,6 +196,33 @@ func (x *Segment) GetDoubleStar() *DoubleStar {
 	return nil
 }
 
+func (x *Segment) GetComposite() *Composite {
+	if x != nil {
+		if x, ok := x.Segment.(*Segment_Composite); ok {
+			return x.Composite
+		}
+	}
+	return nil
+}
+
+func (x *Segment) GetOptional() *Optional {
+	if x != nil {
+		if x, ok := x.Segment.(*Segment_Optional); ok {
+			return x.Optional
+		}
+	}
+	return nil
+}
+
+func (x *Segment) GetAlternation() *Alternation {
+	if x != nil {
+		if x, ok := x.Segment.(*Segment_Alternation); ok {
+			return x.Alternation
+		}
+	}
+	return nil
+}
+
 type isSegment_Segment interface {
 	isSegment_Segment()
 }
@@ -188,6 +243,18 @@ type Segment_DoubleStar struct {
 	DoubleStar *DoubleStar `protobuf:"bytes,4,opt,name=double_star,json=doubleStar,proto3,oneof"`
 }
 
+type Segment_Composite struct {
+	Composite *Composite `protobuf:"bytes,5,opt,name=composite,proto3,oneof"`
+}
+
+type Segment_Optional struct {
+	Optional *Optional `protobuf:"bytes,6,opt,name=optional,proto3,oneof"`
+}
+
+type Segment_Alternation struct {
+	Alternation *Alternation `protobuf:"bytes,7,opt,name=alternation,proto3,oneof"`
+}
+
 func (*Segment_Literal) isSegment_Segment() {}
 
 func (*Segment_Variable) isSegment_Segment() {}
@@ -196,6 +263,12 @@ func (*Segment_Star) isSegment_Segment() {}
 
 func (*Segment_DoubleStar) isSegment_Segment() {}
 
+func (*Segment_Composite) isSegment_Segment() {}
+
+func (*Segment_Optional) isSegment_Segment() {}
+
+func (*Segment_Alternation) isSegment_Segment() {}
+
 // Literal represents a fixed string segment in a path.
 type Literal struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -250,8 +323,8 @@ func (x *Literal) GetValue() string {
 // Limitations:
 //   - Nested variables (e.g., "{outer={inner}}") are not allowed.
 //   - The sub-pattern defined after '=' (e.g., in "{name=pattern}") cannot itself
-//     contain variables. It can only contain literals, single wildcards ('*'),
-//     or a double wildcard ('**') at the very end of the pattern.
+//     contain variables. It can only contain literals, alternations,
+//     wildcards ('*', '**') and composites of literals and '*'.
 type Variable struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The name of the variable, e.g., "id" in "{id}".
@@ -259,7 +332,24 @@ type Variable struct {
 	// Optional. If present, defines a sub-pattern that the variable must match.
 	// e.g., for "{filePath=/docs/**}", segments would represent "/docs/**".
 	// If the variable is simple (e.g., "{id}"), this list will be empty.
-	Segments      []*Segment `protobuf:"bytes,2,rep,name=segments,proto3" json:"segments,omitempty"` // e.g. "foo/*" or "**"
+	Segments []*Segment `protobuf:"bytes,2,rep,name=segments,proto3" json:"segments,omitempty"` // e.g. "foo/*" or "**"
+	// Optional. The name of a registered variable type, e.g., "int" in "{id:int}".
+	// A captured value must satisfy the type's check for the template to match.
+	// If empty, any value is accepted.
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// Optional. An RE2 regular expression the captured value must match in full,
+	// e.g., "[0-9]+" in "{id:[0-9]+}". For a variable with a sub-pattern the
+	// expression is checked against the joined value.
+	// If empty, any value is accepted.
+	Regex string `protobuf:"bytes,4,opt,name=regex,proto3" json:"regex,omitempty"`
+	// Optional. The value reported for the variable when it is inside an
+	// Optional group that was skipped, e.g. "json" in "{format?=json}".
+	// The parser represents an optional variable "{name?}" as an Optional
+	// group holding just the variable.
+	//
+	// A default never overrides a value captured from the path, whatever the
+	// variable merging policy.
+	DefaultValue  string `protobuf:"bytes,5,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -308,6 +398,181 @@ func (x *Variable) GetSegments() []*Segment {
 	return nil
 }
 
+func (x *Variable) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Variable) GetRegex() string {
+	if x != nil {
+		return x.Regex
+	}
+	return ""
+}
+
+func (x *Variable) GetDefaultValue() string {
+	if x != nil {
+		return x.DefaultValue
+	}
+	return ""
+}
+
+// Composite represents a single path segment built from several parts,
+// e.g. "{name}.{ext}", "v{major}" or "{id}-summary".
+//
+// Parts are literals, alternations, wildcards ('*') and simple variables
+// (without a sub-pattern); two variables or wildcards are never adjacent.
+// When matching, each variable takes the longest value that still lets the
+// rest of the segment match, so "{name}.{ext}" splits "archive.tar.gz" into
+// name="archive.tar" and ext="gz". Variables always capture a non-empty value,
+// while a wildcard part matches any, possibly empty, run of characters,
+// as in the glob "*.go".
+type Composite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Parts         []*Segment             `protobuf:"bytes,1,rep,name=parts,proto3" json:"parts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Composite) Reset() {
+	*x = Composite{}
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Composite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Composite) ProtoMessage() {}
+
+func (x *Composite) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Composite.ProtoReflect.Descriptor instead.
+func (*Composite) Descriptor() ([]byte, []int) {
+	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Composite) GetParts() []*Segment {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+// Optional represents a group of segments that may be absent from the path,
+// e.g. "[/profile]" in "/users/{id}[/profile]". Groups can be nested, as in
+// "/reports/{year}[/{month}[/{day}]]".
+//
+// When matching, the group is tried first and skipped if the rest of the
+// template does not match with it. Variables inside a skipped group are
+// absent from the captured variables.
+type Optional struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Segments      []*Segment             `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Optional) Reset() {
+	*x = Optional{}
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Optional) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Optional) ProtoMessage() {}
+
+func (x *Optional) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Optional.ProtoReflect.Descriptor instead.
+func (*Optional) Descriptor() ([]byte, []int) {
+	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Optional) GetSegments() []*Segment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+// Alternation represents a choice between literals, e.g. "(v1|v2)".
+// As a segment it matches exactly one path segment equal to one of the values.
+// It can also be used in a variable's sub-pattern to capture the chosen value,
+// e.g. "{kind=(users|groups)}", or as a part of a composite segment.
+type Alternation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The literal alternatives, in template order.
+	Values        []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alternation) Reset() {
+	*x = Alternation{}
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alternation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alternation) ProtoMessage() {}
+
+func (x *Alternation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alternation.ProtoReflect.Descriptor instead.
+func (*Alternation) Descriptor() ([]byte, []int) {
+	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Alternation) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
 // Star represents a single-segment wildcard character ('*').
 // It matches exactly one segment in the path.
 type Star struct {
@@ -318,7 +583,7 @@ type Star struct {
 
 func (x *Star) Reset() {
 	*x = Star{}
-	mi := &file_proto_v1_pathmatch_proto_msgTypes[4]
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -330,7 +595,7 @@ func (x *Star) String() string {
 func (*Star) ProtoMessage() {}
 
 func (x *Star) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_v1_pathmatch_proto_msgTypes[4]
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -343,12 +608,16 @@ func (x *Star) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Star.ProtoReflect.Descriptor instead.
 func (*Star) Descriptor() ([]byte, []int) {
-	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{4}
+	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{7}
 }
 
 // DoubleStar represents a multi-segment wildcard character ('**').
-// It matches zero or more path segments.
-// It must be the last segment in the main path template or in a variable's sub-pattern.
+// It matches zero or more path segments and may appear anywhere in the
+// template, any number of times.
+//
+// Matching is greedy: '**' first consumes as many segments as it can and
+// gives them back one at a time until the rest of the template matches.
+// So "/a/{x=**}/b/{y=**}" splits "/a/1/b/2/b/3" into x="/1/b/2" and y="/3".
 type DoubleStar struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -357,7 +626,7 @@ type DoubleStar struct {
 
 func (x *DoubleStar) Reset() {
 	*x = DoubleStar{}
-	mi := &file_proto_v1_pathmatch_proto_msgTypes[5]
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -369,7 +638,7 @@ func (x *DoubleStar) String() string {
 func (*DoubleStar) ProtoMessage() {}
 
 func (x *DoubleStar) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_v1_pathmatch_proto_msgTypes[5]
+	mi := &file_proto_v1_pathmatch_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -382,28 +651,41 @@ func (x *DoubleStar) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DoubleStar.ProtoReflect.Descriptor instead.
 func (*DoubleStar) Descriptor() ([]byte, []int) {
-	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{5}
+	return file_proto_v1_pathmatch_proto_rawDescGZIP(), []int{8}
 }
 
 var File_proto_v1_pathmatch_proto protoreflect.FileDescriptor
 
 const file_proto_v1_pathmatch_proto_rawDesc = "" +
 	"\n" +
-	"\x18proto/v1/pathmatch.proto\x12\fpathmatch.v1\"A\n" +
+	"\x18proto/v1/pathmatch.proto\x12\fpathmatch.v1\"U\n" +
 	"\fPathTemplate\x121\n" +
-	"\bsegments\x18\x01 \x03(\v2\x15.pathmatch.v1.SegmentR\bsegments\"\xe4\x01\n" +
+	"\bsegments\x18\x01 \x03(\v2\x15.pathmatch.v1.SegmentR\bsegments\x12\x12\n" +
+	"\x04verb\x18\x02 \x01(\tR\x04verb\"\x92\x03\n" +
 	"\aSegment\x121\n" +
 	"\aliteral\x18\x01 \x01(\v2\x15.pathmatch.v1.LiteralH\x00R\aliteral\x124\n" +
 	"\bvariable\x18\x02 \x01(\v2\x16.pathmatch.v1.VariableH\x00R\bvariable\x12(\n" +
 	"\x04star\x18\x03 \x01(\v2\x12.pathmatch.v1.StarH\x00R\x04star\x12;\n" +
 	"\vdouble_star\x18\x04 \x01(\v2\x18.pathmatch.v1.DoubleStarH\x00R\n" +
-	"doubleStarB\t\n" +
+	"doubleStar\x127\n" +
+	"\tcomposite\x18\x05 \x01(\v2\x17.pathmatch.v1.CompositeH\x00R\tcomposite\x124\n" +
+	"\boptional\x18\x06 \x01(\v2\x16.pathmatch.v1.OptionalH\x00R\boptional\x12=\n" +
+	"\valternation\x18\a \x01(\v2\x19.pathmatch.v1.AlternationH\x00R\valternationB\t\n" +
 	"\asegment\"\x1f\n" +
 	"\aLiteral\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\tR\x05value\"Q\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\"\xa0\x01\n" +
 	"\bVariable\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x121\n" +
-	"\bsegments\x18\x02 \x03(\v2\x15.pathmatch.v1.SegmentR\bsegments\"\x06\n" +
+	"\bsegments\x18\x02 \x03(\v2\x15.pathmatch.v1.SegmentR\bsegments\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x14\n" +
+	"\x05regex\x18\x04 \x01(\tR\x05regex\x12#\n" +
+	"\rdefault_value\x18\x05 \x01(\tR\fdefaultValue\"8\n" +
+	"\tComposite\x12+\n" +
+	"\x05parts\x18\x01 \x03(\v2\x15.pathmatch.v1.SegmentR\x05parts\"=\n" +
+	"\bOptional\x121\n" +
+	"\bsegments\x18\x01 \x03(\v2\x15.pathmatch.v1.SegmentR\bsegments\"%\n" +
+	"\vAlternation\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\x06\n" +
 	"\x04Star\"\f\n" +
 	"\n" +
 	"DoubleStarB7Z5github.com/tsdkv/pathmatch/pathmatchpb/v1;pathmatchpbb\x06proto3"
@@ -420,27 +702,35 @@ func file_proto_v1_pathmatch_proto_rawDescGZIP() []byte {
 	return file_proto_v1_pathmatch_proto_rawDescData
 }
 
-var file_proto_v1_pathmatch_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_v1_pathmatch_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_proto_v1_pathmatch_proto_goTypes = []any{
 	(*PathTemplate)(nil), // 0: pathmatch.v1.PathTemplate
 	(*Segment)(nil),      // 1: pathmatch.v1.Segment
 	(*Literal)(nil),      // 2: pathmatch.v1.Literal
 	(*Variable)(nil),     // 3: pathmatch.v1.Variable
-	(*Star)(nil),         // 4: pathmatch.v1.Star
-	(*DoubleStar)(nil),   // 5: pathmatch.v1.DoubleStar
+	(*Composite)(nil),    // 4: pathmatch.v1.Composite
+	(*Optional)(nil),     // 5: pathmatch.v1.Optional
+	(*Alternation)(nil),  // 6: pathmatch.v1.Alternation
+	(*Star)(nil),         // 7: pathmatch.v1.Star
+	(*DoubleStar)(nil),   // 8: pathmatch.v1.DoubleStar
 }
 var file_proto_v1_pathmatch_proto_depIdxs = []int32{
-	1, // 0: pathmatch.v1.PathTemplate.segments:type_name -> pathmatch.v1.Segment
-	2, // 1: pathmatch.v1.Segment.literal:type_name -> pathmatch.v1.Literal
-	3, // 2: pathmatch.v1.Segment.variable:type_name -> pathmatch.v1.Variable
-	4, // 3: pathmatch.v1.Segment.star:type_name -> pathmatch.v1.Star
-	5, // 4: pathmatch.v1.Segment.double_star:type_name -> pathmatch.v1.DoubleStar
-	1, // 5: pathmatch.v1.Variable.segments:type_name -> pathmatch.v1.Segment
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	1,  // 0: pathmatch.v1.PathTemplate.segments:type_name -> pathmatch.v1.Segment
+	2,  // 1: pathmatch.v1.Segment.literal:type_name -> pathmatch.v1.Literal
+	3,  // 2: pathmatch.v1.Segment.variable:type_name -> pathmatch.v1.Variable
+	7,  // 3: pathmatch.v1.Segment.star:type_name -> pathmatch.v1.Star
+	8,  // 4: pathmatch.v1.Segment.double_star:type_name -> pathmatch.v1.DoubleStar
+	4,  // 5: pathmatch.v1.Segment.composite:type_name -> pathmatch.v1.Composite
+	5,  // 6: pathmatch.v1.Segment.optional:type_name -> pathmatch.v1.Optional
+	6,  // 7: pathmatch.v1.Segment.alternation:type_name -> pathmatch.v1.Alternation
+	1,  // 8: pathmatch.v1.Variable.segments:type_name -> pathmatch.v1.Segment
+	1,  // 9: pathmatch.v1.Composite.parts:type_name -> pathmatch.v1.Segment
+	1,  // 10: pathmatch.v1.Optional.segments:type_name -> pathmatch.v1.Segment
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_proto_v1_pathmatch_proto_init() }
@@ -453,6 +743,9 @@ func file_proto_v1_pathmatch_proto_init() {
 		(*Segment_Variable)(nil),
 		(*Segment_Star)(nil),
 		(*Segment_DoubleStar)(nil),
+		(*Segment_Composite)(nil),
+		(*Segment_Optional)(nil),
+		(*Segment_Alternation)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -460,7 +753,7 @@ func file_proto_v1_pathmatch_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_v1_pathmatch_proto_rawDesc), len(file_proto_v1_pathmatch_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
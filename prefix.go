@@ -0,0 +1,56 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/internal/utils"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Result is the result of matching a template against a prefix of a path.
+type Result struct {
+	// Matched reports whether the template matched a prefix of the path.
+	Matched bool
+	// Prefix is the part of the path matched by the template, e.g. "/tenants/acme".
+	Prefix string
+	// Remaining is the rest of the path, e.g. "/users/1", or "" if the
+	// template matched the whole path.
+	Remaining string
+	// Vars are the variables captured from the prefix.
+	Vars map[string]string
+}
+
+// MatchPrefix matches a parsed template against the beginning of path, so
+// that a mount point like "/tenants/{tenant}" can hand the rest of the path
+// to another component. '**' and optional groups are greedy: the longest
+// matching prefix is found first.
+//
+// Example:
+//
+//	tmpl, _ := pathmatch.ParseTemplate("/tenants/{tenant}")
+//	res, _ := pathmatch.MatchPrefix(tmpl, "/tenants/acme/users/1")
+//	// res.Matched: true
+//	// res.Prefix: "/tenants/acme"
+//	// res.Remaining: "/users/1"
+//	// res.Vars: map[string]string{"tenant": "acme"}
+func MatchPrefix(template *pathmatchpb.PathTemplate, path string, opts ...MatchOption) (Result, error) {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+
+	segments := utils.Split(path)
+	matched, n, vars, err := match.MatchPrefix(template, segments, mopts)
+	if !matched || err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Matched: true,
+		Prefix:  utils.Join(segments[:n]...),
+		Vars:    vars,
+	}
+	if n < len(segments) {
+		res.Remaining = utils.Join(segments[n:]...)
+	}
+	return res, nil
+}
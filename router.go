@@ -0,0 +1,54 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/match"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// ErrDuplicateID is returned when a template is added to a Router under an
+// id that is already in use.
+var ErrDuplicateID = match.ErrDuplicateID
+
+// Router matches a path against a set of templates in a single pass.
+// The templates are indexed in a trie of path segments: literal segments
+// are found with a map lookup, while wildcards, variables and '**' are
+// fallback edges tried only when the literal edges do not lead to a match.
+//
+// When several templates match a path, the most specific one wins, as
+// defined by Compare. Among equally specific templates, the one added
+// first wins.
+//
+// A Router is not safe for concurrent use while templates are being added.
+// Once all templates are added, Match can be called concurrently.
+//
+// Example:
+//
+//	router := pathmatch.NewRouter()
+//	router.Add("get-user", userTemplate) // "/users/{id}"
+//	router.Add("me", meTemplate)         // "/users/me"
+//	id, vars, ok, _ := router.Match("/users/alice")
+//	// id: "get-user", vars: map[string]string{"id": "alice"}, ok: true
+type Router struct {
+	r *match.Router
+}
+
+// NewRouter creates an empty Router. The options apply to every match.
+func NewRouter(opts ...MatchOption) *Router {
+	mopts := &match.MatchOptions{}
+	for _, opt := range opts {
+		opt(mopts)
+	}
+	return &Router{r: match.NewRouter(mopts)}
+}
+
+// Add adds a parsed template to the router under the given id.
+// It returns ErrDuplicateID if the id is already in use.
+func (r *Router) Add(id string, template *pathmatchpb.PathTemplate) error {
+	return r.r.Add(id, template)
+}
+
+// Match matches the whole path against the templates of the router.
+// It returns the id of the matching template and the variables it captured.
+func (r *Router) Match(path string) (id string, vars map[string]string, matched bool, err error) {
+	return r.r.Match(path)
+}
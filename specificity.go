@@ -0,0 +1,37 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/rank"
+	"github.com/tsdkv/pathmatch/pathmatchpb/v1"
+)
+
+// Compare compares the specificity of two templates. It returns a negative
+// number if a is more specific than b, a positive number if b is more
+// specific than a, and zero if neither is. When several templates match a
+// path, the most specific one should win; Router follows this rule.
+//
+// The templates are compared segment by segment, and the first pair of
+// segments of different specificity decides. From the most to the least
+// specific, a segment is:
+//  1. a literal, e.g. "users"
+//  2. an alternation, e.g. "(v1|v2)"
+//  3. a composite segment, e.g. "{name}.{ext}"
+//  4. a variable with a sub-pattern, e.g. "{name=shelves/*}"
+//  5. a single-segment variable or '*'; "{id}", "{id:int}", "{id=*}" and '*'
+//     are equal
+//  6. an optional group, e.g. "[/{page}]"
+//  7. a double wildcard '**'
+//
+// Sub-patterns, composite segments and optional groups of the same kind are
+// compared by their contents, in the same way. If all segments compare equal
+// and one template has fewer segments, it is the more specific one. Finally,
+// a template with a custom verb is more specific than one without.
+func Compare(a, b *pathmatchpb.PathTemplate) int {
+	return rank.Compare(a, b)
+}
+
+// SortBySpecificity sorts templates from the most to the least specific,
+// as defined by Compare. The order of equally specific templates is kept.
+func SortBySpecificity(templates []*pathmatchpb.PathTemplate) {
+	rank.Sort(templates)
+}
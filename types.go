@@ -0,0 +1,36 @@
+package pathmatch
+
+import (
+	"github.com/tsdkv/pathmatch/internal/vartype"
+)
+
+var (
+	// ErrUnknownType is returned when a template refers to a variable type
+	// that has not been registered.
+	ErrUnknownType = vartype.ErrUnknownType
+	// ErrInvalidTypeName is returned by RegisterType when the type name is not an identifier.
+	ErrInvalidTypeName = vartype.ErrInvalidTypeName
+	// ErrDuplicateType is returned by RegisterType when the type name is already taken.
+	ErrDuplicateType = vartype.ErrDuplicateType
+)
+
+// RegisterType registers a variable type that can be used in templates
+// as "{name:type}". The check function reports whether a captured value
+// belongs to the type; a path whose value fails the check does not match.
+//
+// The following types are registered by default:
+//   - string: any non-empty value
+//   - int: a base-10 signed 64-bit integer, e.g. "42" or "-7"
+//   - uuid: a UUID in the canonical 8-4-4-4-12 hexadecimal form
+//   - date: a calendar date in the YYYY-MM-DD form
+//
+// Types are usually registered from an init function, before any template
+// using them is parsed. Example:
+//
+//	pathmatch.RegisterType("slug", func(s string) bool {
+//		return slugRe.MatchString(s)
+//	})
+//	tmpl, _ := pathmatch.ParseTemplate("/posts/{post:slug}")
+func RegisterType(name string, check func(value string) bool) error {
+	return vartype.Register(name, check)
+}
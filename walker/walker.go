@@ -134,7 +134,7 @@ func NewWalker(path string) *Walker {
 //	// walker.Variables(): map[string]string{"id": "alice"}
 //	// walker.Depth(): 1
 func (w *Walker) Step(template *pathmatchpb.PathTemplate) (stepVars map[string]string, matched bool, err error) {
-	matched, pathIdx, vars, err := match.Match(template, w.pathSegments[w.pathSegIdx:], w.matchOptions)
+	matched, pathIdx, vars, err := match.MatchPrefix(template, w.pathSegments[w.pathSegIdx:], w.matchOptions)
 	if err != nil {
 		return nil, false, err
 	}